@@ -1 +1,232 @@
 package jdwp
+
+import "fmt"
+
+// ErrorCode represents a JDWP error code
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Error
+//
+// ErrorCode implements error so that it can be used as the target
+// of errors.Is against an *Error returned from a command
+type ErrorCode uint16
+
+const (
+	// ErrorNone - no error has occurred
+	ErrorNone ErrorCode = 0
+	// ErrorInvalidThread - passed thread is null, is not a valid thread or has exited
+	ErrorInvalidThread ErrorCode = 10
+	// ErrorInvalidThreadGroup - thread group invalid
+	ErrorInvalidThreadGroup ErrorCode = 11
+	// ErrorInvalidPriority - invalid priority
+	ErrorInvalidPriority ErrorCode = 12
+	// ErrorThreadNotSuspended - the specified thread has not been suspended by an event
+	ErrorThreadNotSuspended ErrorCode = 13
+	// ErrorThreadSuspended - thread already suspended
+	ErrorThreadSuspended ErrorCode = 14
+	// ErrorThreadNotAlive - thread has not been started or is now dead
+	ErrorThreadNotAlive ErrorCode = 15
+	// ErrorInvalidObject - the reference type id or object id is not valid
+	ErrorInvalidObject ErrorCode = 20
+	// ErrorInvalidClass - invalid class
+	ErrorInvalidClass ErrorCode = 21
+	// ErrorClassNotPrepared - class has been loaded but not yet prepared
+	ErrorClassNotPrepared ErrorCode = 22
+	// ErrorInvalidMethodID - invalid method
+	ErrorInvalidMethodID ErrorCode = 23
+	// ErrorInvalidLocation - invalid location
+	ErrorInvalidLocation ErrorCode = 24
+	// ErrorInvalidFieldID - invalid field
+	ErrorInvalidFieldID ErrorCode = 25
+	// ErrorInvalidFrameID - invalid jframeid
+	ErrorInvalidFrameID ErrorCode = 30
+	// ErrorNoMoreFrames - there are no more java or jni frames on the call stack
+	ErrorNoMoreFrames ErrorCode = 31
+	// ErrorOpaqueFrame - information about the frame is not available
+	ErrorOpaqueFrame ErrorCode = 32
+	// ErrorNotCurrentFrame - operation can only be performed on current frame
+	ErrorNotCurrentFrame ErrorCode = 33
+	// ErrorTypeMismatch - the variable is not an appropriate type for the function used
+	ErrorTypeMismatch ErrorCode = 34
+	// ErrorInvalidSlot - invalid slot
+	ErrorInvalidSlot ErrorCode = 35
+	// ErrorDuplicate - item already set
+	ErrorDuplicate ErrorCode = 40
+	// ErrorNotFound - desired element not found
+	ErrorNotFound ErrorCode = 41
+	// ErrorInvalidModule - invalid module
+	ErrorInvalidModule ErrorCode = 42
+	// ErrorInvalidMonitor - invalid monitor
+	ErrorInvalidMonitor ErrorCode = 50
+	// ErrorNotMonitorOwner - this thread doesn't own the monitor
+	ErrorNotMonitorOwner ErrorCode = 51
+	// ErrorInterrupt - the call has been interrupted before completion
+	ErrorInterrupt ErrorCode = 52
+	// ErrorInvalidClassFormat - the virtual machine attempted to read a class file and determined that the file is malformed
+	ErrorInvalidClassFormat ErrorCode = 60
+	// ErrorCircularClassDefinition - a circularity has been detected while initializing a class
+	ErrorCircularClassDefinition ErrorCode = 61
+	// ErrorFailsVerification - the verifier detected that a class file could not be used
+	ErrorFailsVerification ErrorCode = 62
+	// ErrorAddMethodNotImplemented - adding methods has not been implemented
+	ErrorAddMethodNotImplemented ErrorCode = 63
+	// ErrorSchemaChangeNotImplemented - schema change has not been implemented
+	ErrorSchemaChangeNotImplemented ErrorCode = 64
+	// ErrorInvalidTypestate - the state of the thread has been modified, and is now inconsistent
+	ErrorInvalidTypestate ErrorCode = 65
+	// ErrorHierarchyChangeNotImplemented - a direct superclass is different or the set of directly implemented interfaces is different
+	ErrorHierarchyChangeNotImplemented ErrorCode = 66
+	// ErrorDeleteMethodNotImplemented - the new class version does not declare a method declared in the old class version
+	ErrorDeleteMethodNotImplemented ErrorCode = 67
+	// ErrorUnsupportedVersion - a class file has a version number not supported by this vm
+	ErrorUnsupportedVersion ErrorCode = 68
+	// ErrorNamesDontMatch - the class name defined in the new class file is different from the name in the old class object
+	ErrorNamesDontMatch ErrorCode = 69
+	// ErrorClassModifiersChangeNotImplemented - the new class version has different modifiers
+	ErrorClassModifiersChangeNotImplemented ErrorCode = 70
+	// ErrorMethodModifiersChangeNotImplemented - a method in the new class version has different modifiers than its counterpart in the old class version
+	ErrorMethodModifiersChangeNotImplemented ErrorCode = 71
+	// ErrorClassAttributeChangeNotImplemented - the new class version has a different nesthost, nestmembers, permittedsubclasses, or record class attribute
+	ErrorClassAttributeChangeNotImplemented ErrorCode = 72
+	// ErrorNotImplemented - the functionality is not implemented in this virtual machine
+	ErrorNotImplemented ErrorCode = 99
+	// ErrorNullPointer - invalid pointer
+	ErrorNullPointer ErrorCode = 100
+	// ErrorAbsentInformation - desired information is not available
+	ErrorAbsentInformation ErrorCode = 101
+	// ErrorInvalidEventType - the specified event type id is not recognized
+	ErrorInvalidEventType ErrorCode = 102
+	// ErrorIllegalArgument - illegal argument
+	ErrorIllegalArgument ErrorCode = 103
+	// ErrorOutOfMemory - the function needed to allocate memory and no more memory was available for allocation
+	ErrorOutOfMemory ErrorCode = 110
+	// ErrorAccessDenied - debugging has not been enabled in this virtual machine
+	ErrorAccessDenied ErrorCode = 111
+	// ErrorVmDead - the virtual machine is not running
+	ErrorVmDead ErrorCode = 112
+	// ErrorInternal - an unexpected internal error has occurred
+	ErrorInternal ErrorCode = 113
+	// ErrorUnattachedThread - the thread being used to call this function is not attached to the virtual machine
+	ErrorUnattachedThread ErrorCode = 115
+	// ErrorInvalidTag - object type id or class tag
+	ErrorInvalidTag ErrorCode = 500
+	// ErrorAlreadyInvoking - previous invoke not complete
+	ErrorAlreadyInvoking ErrorCode = 502
+	// ErrorInvalidIndex - index is invalid
+	ErrorInvalidIndex ErrorCode = 503
+	// ErrorInvalidLength - the length is invalid
+	ErrorInvalidLength ErrorCode = 504
+	// ErrorInvalidString - the string is invalid
+	ErrorInvalidString ErrorCode = 506
+	// ErrorInvalidClassLoader - the class loader is invalid
+	ErrorInvalidClassLoader ErrorCode = 507
+	// ErrorInvalidArray - the array is invalid
+	ErrorInvalidArray ErrorCode = 508
+	// ErrorTransportLoad - unable to load the transport
+	ErrorTransportLoad ErrorCode = 509
+	// ErrorTransportInit - unable to initialize the transport
+	ErrorTransportInit ErrorCode = 510
+	// ErrorNativeMethod - native method
+	ErrorNativeMethod ErrorCode = 511
+	// ErrorInvalidCount - the count is invalid
+	ErrorInvalidCount ErrorCode = 512
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrorNone:                                "NONE",
+	ErrorInvalidThread:                       "INVALID_THREAD",
+	ErrorInvalidThreadGroup:                  "INVALID_THREAD_GROUP",
+	ErrorInvalidPriority:                     "INVALID_PRIORITY",
+	ErrorThreadNotSuspended:                  "THREAD_NOT_SUSPENDED",
+	ErrorThreadSuspended:                     "THREAD_SUSPENDED",
+	ErrorThreadNotAlive:                      "THREAD_NOT_ALIVE",
+	ErrorInvalidObject:                       "INVALID_OBJECT",
+	ErrorInvalidClass:                        "INVALID_CLASS",
+	ErrorClassNotPrepared:                    "CLASS_NOT_PREPARED",
+	ErrorInvalidMethodID:                     "INVALID_METHODID",
+	ErrorInvalidLocation:                     "INVALID_LOCATION",
+	ErrorInvalidFieldID:                      "INVALID_FIELDID",
+	ErrorInvalidFrameID:                      "INVALID_FRAMEID",
+	ErrorNoMoreFrames:                        "NO_MORE_FRAMES",
+	ErrorOpaqueFrame:                         "OPAQUE_FRAME",
+	ErrorNotCurrentFrame:                     "NOT_CURRENT_FRAME",
+	ErrorTypeMismatch:                        "TYPE_MISMATCH",
+	ErrorInvalidSlot:                         "INVALID_SLOT",
+	ErrorDuplicate:                           "DUPLICATE",
+	ErrorNotFound:                            "NOT_FOUND",
+	ErrorInvalidModule:                       "INVALID_MODULE",
+	ErrorInvalidMonitor:                      "INVALID_MONITOR",
+	ErrorNotMonitorOwner:                     "NOT_MONITOR_OWNER",
+	ErrorInterrupt:                           "INTERRUPT",
+	ErrorInvalidClassFormat:                  "INVALID_CLASS_FORMAT",
+	ErrorCircularClassDefinition:             "CIRCULAR_CLASS_DEFINITION",
+	ErrorFailsVerification:                   "FAILS_VERIFICATION",
+	ErrorAddMethodNotImplemented:             "ADD_METHOD_NOT_IMPLEMENTED",
+	ErrorSchemaChangeNotImplemented:          "SCHEMA_CHANGE_NOT_IMPLEMENTED",
+	ErrorInvalidTypestate:                    "INVALID_TYPESTATE",
+	ErrorHierarchyChangeNotImplemented:       "HIERARCHY_CHANGE_NOT_IMPLEMENTED",
+	ErrorDeleteMethodNotImplemented:          "DELETE_METHOD_NOT_IMPLEMENTED",
+	ErrorUnsupportedVersion:                  "UNSUPPORTED_VERSION",
+	ErrorNamesDontMatch:                      "NAMES_DONT_MATCH",
+	ErrorClassModifiersChangeNotImplemented:  "CLASS_MODIFIERS_CHANGE_NOT_IMPLEMENTED",
+	ErrorMethodModifiersChangeNotImplemented: "METHOD_MODIFIERS_CHANGE_NOT_IMPLEMENTED",
+	ErrorClassAttributeChangeNotImplemented:  "CLASS_ATTRIBUTE_CHANGE_NOT_IMPLEMENTED",
+	ErrorNotImplemented:                      "NOT_IMPLEMENTED",
+	ErrorNullPointer:                         "NULL_POINTER",
+	ErrorAbsentInformation:                   "ABSENT_INFORMATION",
+	ErrorInvalidEventType:                    "INVALID_EVENT_TYPE",
+	ErrorIllegalArgument:                     "ILLEGAL_ARGUMENT",
+	ErrorOutOfMemory:                         "OUT_OF_MEMORY",
+	ErrorAccessDenied:                        "ACCESS_DENIED",
+	ErrorVmDead:                              "VM_DEAD",
+	ErrorInternal:                            "INTERNAL",
+	ErrorUnattachedThread:                    "UNATTACHED_THREAD",
+	ErrorInvalidTag:                          "INVALID_TAG",
+	ErrorAlreadyInvoking:                     "ALREADY_INVOKING",
+	ErrorInvalidIndex:                        "INVALID_INDEX",
+	ErrorInvalidLength:                       "INVALID_LENGTH",
+	ErrorInvalidString:                       "INVALID_STRING",
+	ErrorInvalidClassLoader:                  "INVALID_CLASS_LOADER",
+	ErrorInvalidArray:                        "INVALID_ARRAY",
+	ErrorTransportLoad:                       "TRANSPORT_LOAD",
+	ErrorTransportInit:                       "TRANSPORT_INIT",
+	ErrorNativeMethod:                        "NATIVE_METHOD",
+	ErrorInvalidCount:                        "INVALID_COUNT",
+}
+
+func (e ErrorCode) String() string {
+	if name, ok := errorCodeNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN_ERROR(%d)", uint16(e))
+}
+
+func (e ErrorCode) Error() string {
+	return e.String()
+}
+
+// Error represents a non zero error code returned by the VM in
+// reply to a command
+type Error struct {
+	ErrorCode  ErrorCode
+	Commandset byte
+	Command    byte
+}
+
+// NewError creates a new Error for the given command
+func NewError(errorCode ErrorCode, cmd Command) *Error {
+	return &Error{
+		ErrorCode:  errorCode,
+		Commandset: cmd.Commandset,
+		Command:    cmd.Command,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jdwp error %v (%d) commandset=%v command=%v",
+		e.ErrorCode, uint16(e.ErrorCode), e.Commandset, e.Command)
+}
+
+// Is reports whether target is the ErrorCode carried by e
+func (e *Error) Is(target error) bool {
+	errorCode, ok := target.(ErrorCode)
+	return ok && errorCode == e.ErrorCode
+}
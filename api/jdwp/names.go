@@ -0,0 +1,170 @@
+package jdwp
+
+import "fmt"
+
+type commandset struct {
+	name     string
+	commands map[byte]string
+}
+
+// commandsets names every command set and command in the JDWP
+// specification, including those not implemented by this library
+// https://docs.oracle.com/en/java/javase/21/docs/specs/jdwp/jdwp-protocol.html
+var commandsets = map[byte]commandset{
+	1: {"VirtualMachine", map[byte]string{
+		1:  "Version",
+		2:  "ClassesBySignature",
+		3:  "AllClasses",
+		4:  "AllThreads",
+		5:  "TopLevelThreadGroups",
+		6:  "Dispose",
+		7:  "IDSizes",
+		8:  "Suspend",
+		9:  "Resume",
+		10: "Exit",
+		11: "CreateString",
+		12: "Capabilities",
+		13: "ClassPaths",
+		14: "DisposeObjects",
+		15: "HoldEvents",
+		16: "ReleaseEvents",
+		17: "CapabilitiesNew",
+		18: "RedefineClasses",
+		19: "SetDefaultStratum",
+		20: "AllClassesWithGeneric",
+		21: "InstanceCounts",
+		22: "AllModules",
+	}},
+	2: {"ReferenceType", map[byte]string{
+		1:  "Signature",
+		2:  "ClassLoader",
+		3:  "Modifiers",
+		4:  "Fields",
+		5:  "Methods",
+		6:  "GetValues",
+		7:  "SourceFile",
+		8:  "NestedTypes",
+		9:  "Status",
+		10: "Interfaces",
+		11: "ClassObject",
+		12: "SourceDebugExtension",
+		13: "SignatureWithGeneric",
+		14: "FieldsWithGeneric",
+		15: "MethodsWithGeneric",
+		16: "Instances",
+		17: "ClassFileVersion",
+		18: "ConstantPool",
+		19: "Module",
+	}},
+	3: {"ClassType", map[byte]string{
+		1: "Superclass",
+		2: "SetValues",
+		3: "InvokeMethod",
+		4: "NewInstance",
+	}},
+	4: {"ArrayType", map[byte]string{
+		1: "NewInstance",
+	}},
+	5: {"InterfaceType", map[byte]string{
+		1: "InvokeMethod",
+	}},
+	6: {"Method", map[byte]string{
+		1: "LineTable",
+		2: "VariableTable",
+		3: "Bytecodes",
+		4: "IsObsolete",
+		5: "VariableTableWithGeneric",
+	}},
+	8: {"Field", map[byte]string{}},
+	9: {"ObjectReference", map[byte]string{
+		1:  "ReferenceType",
+		2:  "GetValues",
+		3:  "SetValues",
+		5:  "MonitorInfo",
+		6:  "InvokeMethod",
+		7:  "DisableCollection",
+		8:  "EnableCollection",
+		9:  "IsCollected",
+		10: "ReferringObjects",
+	}},
+	10: {"StringReference", map[byte]string{
+		1: "Value",
+	}},
+	11: {"ThreadReference", map[byte]string{
+		1:  "Name",
+		2:  "Suspend",
+		3:  "Resume",
+		4:  "Status",
+		5:  "ThreadGroup",
+		6:  "Frames",
+		7:  "FrameCount",
+		8:  "OwnedMonitors",
+		9:  "CurrentContendedMonitor",
+		10: "Stop",
+		11: "Interrupt",
+		12: "SuspendCount",
+		13: "OwnedMonitorsStackDepthInfo",
+		14: "ForceEarlyReturn",
+		15: "IsVirtual",
+	}},
+	12: {"ThreadGroupReference", map[byte]string{
+		1: "Name",
+		2: "Parent",
+		3: "Children",
+	}},
+	13: {"ArrayReference", map[byte]string{
+		1: "Length",
+		2: "GetValues",
+		3: "SetValues",
+	}},
+	14: {"ClassLoaderReference", map[byte]string{
+		1: "VisibleClasses",
+	}},
+	15: {"EventRequest", map[byte]string{
+		1: "Set",
+		2: "Clear",
+		3: "ClearAllBreakpoints",
+	}},
+	16: {"StackFrame", map[byte]string{
+		1: "GetValues",
+		2: "SetValues",
+		3: "ThisObject",
+		4: "PopFrames",
+	}},
+	17: {"ClassObjectReference", map[byte]string{
+		1: "ReflectedType",
+	}},
+	18: {"ModuleReference", map[byte]string{
+		1: "Name",
+		2: "ClassLoader",
+	}},
+	64: {"Event", map[byte]string{
+		100: "Composite",
+	}},
+}
+
+// CommandsetName returns the name of a command set, such as
+// "VirtualMachine"
+func CommandsetName(commandset byte) string {
+	if c, ok := commandsets[commandset]; ok {
+		return c.name
+	}
+	return fmt.Sprintf("Unknown(%d)", commandset)
+}
+
+// CommandName returns the name of a command within its command set,
+// such as "Version"
+func CommandName(commandset byte, command byte) string {
+	if c, ok := commandsets[commandset]; ok {
+		if name, ok := c.commands[command]; ok {
+			return name
+		}
+	}
+	return fmt.Sprintf("Unknown(%d)", command)
+}
+
+// Name returns the qualified name of the command, such as
+// "VirtualMachine.Version"
+func (c Command) Name() string {
+	return CommandsetName(c.Commandset) + "." + CommandName(c.Commandset, c.Command)
+}
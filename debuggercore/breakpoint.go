@@ -0,0 +1,602 @@
+package debuggercore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const (
+	// accNative is the ACC_NATIVE method access flag
+	accNative = 0x0100
+	// accAbstract is the ACC_ABSTRACT method access flag
+	accAbstract = 0x0400
+)
+
+// ErrNoCodeAtLine is returned when a class has no code at the line a
+// breakpoint names
+var ErrNoCodeAtLine = errors.New("no code at line")
+
+// BreakpointCommands manage breakpoints set by source position or
+// method name, rather than by location.
+//
+// A breakpoint on a class that is not loaded yet is deferred: it is
+// installed when the class is prepared, and again for every class
+// loader that later loads a class of the same name. A breakpoint on a
+// line is also installed in the class's nested classes, such as
+// com.foo.Bar$1 and com.foo.Bar$Inner, as they share its source file
+// and lines. Breakpoint events
+// are delivered through EventCommands as usual; Find maps their
+// request IDs back to the breakpoint that set them
+type BreakpointCommands interface {
+	// Add parses spec, which is either "com.foo.Bar:42" or
+	// "com.foo.Bar.baz(Ljava/lang/String;)V" (the signature may be
+	// left off if baz is not overloaded), and sets the breakpoint with
+	// the given suspend policy
+	Add(spec string, suspendPolicy event.SuspendPolicy) (*Breakpoint, error)
+	Remove(*Breakpoint) error
+	// List returns the breakpoints in the order they were added
+	List() []*Breakpoint
+	// Find returns the breakpoint that owns a breakpoint event request
+	Find(requestID int32) (*Breakpoint, bool)
+}
+
+// BreakpointSpec is a parsed breakpoint spec. Exactly one of Line and
+// MethodName is set
+type BreakpointSpec struct {
+	ClassName       string
+	Line            int32
+	MethodName      string
+	MethodSignature string
+}
+
+// ParseBreakpointSpec parses "com.foo.Bar:42", "com.foo.Bar.baz" or
+// "com.foo.Bar.baz(Ljava/lang/String;)V"
+func ParseBreakpointSpec(spec string) (*BreakpointSpec, error) {
+	if colon := strings.LastIndex(spec, ":"); colon >= 0 {
+		line, err := strconv.ParseInt(spec[colon+1:], 10, 32)
+		if err != nil || line <= 0 || colon == 0 {
+			return nil, fmt.Errorf("bad breakpoint spec %q: expected class:line", spec)
+		}
+		return &BreakpointSpec{ClassName: spec[:colon], Line: int32(line)}, nil
+	}
+
+	name, signature := spec, ""
+	if paren := strings.Index(spec, "("); paren >= 0 {
+		name, signature = spec[:paren], spec[paren:]
+	}
+	dot := strings.LastIndex(name, ".")
+	if dot <= 0 || dot == len(name)-1 {
+		return nil, fmt.Errorf("bad breakpoint spec %q: expected class:line or class.method(signature)", spec)
+	}
+	return &BreakpointSpec{
+		ClassName:       name[:dot],
+		MethodName:      name[dot+1:],
+		MethodSignature: signature,
+	}, nil
+}
+
+func (b BreakpointSpec) String() string {
+	if b.MethodName != "" {
+		return fmt.Sprintf("%s.%s%s", b.ClassName, b.MethodName, b.MethodSignature)
+	}
+	return fmt.Sprintf("%s:%d", b.ClassName, b.Line)
+}
+
+// Breakpoint is a breakpoint set through BreakpointCommands. It is
+// pending until its class is loaded, after which it has a location and
+// an event request per loaded copy of the class
+type Breakpoint struct {
+	ID            int
+	Spec          BreakpointSpec
+	SuspendPolicy event.SuspendPolicy
+
+	mutex      sync.Mutex
+	classes    map[basetypes.JWDPRefTypeID]struct{}
+	locations  []common.Location
+	requestIDs []int32
+	err        error
+}
+
+// Resolved reports whether the breakpoint has been installed in at
+// least one class
+func (b *Breakpoint) Resolved() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.requestIDs) > 0
+}
+
+// Locations returns the locations the breakpoint is installed at
+func (b *Breakpoint) Locations() []common.Location {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]common.Location(nil), b.locations...)
+}
+
+// RequestIDs returns the IDs of the breakpoint's event requests
+func (b *Breakpoint) RequestIDs() []int32 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]int32(nil), b.requestIDs...)
+}
+
+// Owns reports whether requestID is one of the breakpoint's requests
+func (b *Breakpoint) Owns(requestID int32) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, id := range b.requestIDs {
+		if id == requestID {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the last error resolving a deferred breakpoint, for
+// example ErrNoCodeAtLine once its class has been loaded
+func (b *Breakpoint) Err() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.err
+}
+
+func (b *Breakpoint) String() string {
+	state := "pending"
+	if err := b.Err(); err != nil {
+		state = err.Error()
+	} else if b.Resolved() {
+		state = "resolved"
+	}
+	return fmt.Sprintf("breakpoint %d %s (%s)", b.ID, b.Spec, state)
+}
+
+// classPrepare is the ClassPrepare request shared by every breakpoint
+// and exception breakpoint on a class name pattern; sharing means a
+// class load suspends and resumes its thread once however many
+// breakpoints are waiting on it
+type classPrepare struct {
+	pattern     string
+	requestID   int32
+	breakpoints []*Breakpoint
+	exceptions  []*ExceptionBreakpoint
+}
+
+// breakpointManager is shared by every copy of a debuggercore made by
+// WithContext. It also defers exception breakpoints, so that a class
+// named by both kinds of breakpoint is waited on by one request
+type breakpointManager struct {
+	core         *debuggercore
+	mutex        sync.Mutex
+	nextID       int
+	breakpoints  []*Breakpoint
+	prepares     map[string]*classPrepare
+	requests     map[int32]*classPrepare
+	subscription EventSubscription
+}
+
+func newBreakpointManager(core *debuggercore) *breakpointManager {
+	return &breakpointManager{
+		core:     core,
+		prepares: make(map[string]*classPrepare),
+		requests: make(map[int32]*classPrepare),
+	}
+}
+
+type breakpointCommands struct {
+	*debuggercore
+}
+
+func (b *breakpointCommands) Add(spec string, suspendPolicy event.SuspendPolicy) (*Breakpoint, error) {
+	parsed, err := ParseBreakpointSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	return b.breakpoints.add(b.debuggercore, *parsed, suspendPolicy)
+}
+
+func (b *breakpointCommands) Remove(breakpoint *Breakpoint) error {
+	return b.breakpoints.remove(b.debuggercore, breakpoint)
+}
+
+func (b *breakpointCommands) List() []*Breakpoint {
+	b.breakpoints.mutex.Lock()
+	defer b.breakpoints.mutex.Unlock()
+	return append([]*Breakpoint(nil), b.breakpoints.breakpoints...)
+}
+
+func (b *breakpointCommands) Find(requestID int32) (*Breakpoint, bool) {
+	for _, breakpoint := range b.List() {
+		if breakpoint.Owns(requestID) {
+			return breakpoint, true
+		}
+	}
+	return nil, false
+}
+
+func (m *breakpointManager) add(d *debuggercore, spec BreakpointSpec, suspendPolicy event.SuspendPolicy) (*Breakpoint, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// The ClassPrepare requests go in before looking for loaded copies
+	// of the class, so that one loaded in between is not missed; install
+	// ignores a class it has already seen
+	var prepares []*classPrepare
+	var err error
+	for _, pattern := range classPatterns(spec) {
+		var prepare *classPrepare
+		if prepare, err = m.classPrepare(d, pattern); err != nil {
+			break
+		}
+		prepares = append(prepares, prepare)
+	}
+	breakpoint := &Breakpoint{
+		ID:            m.nextID + 1,
+		Spec:          spec,
+		SuspendPolicy: suspendPolicy,
+		classes:       make(map[basetypes.JWDPRefTypeID]struct{}),
+	}
+	var loaded []loadedClass
+	if err == nil {
+		loaded, err = d.loadedClasses(spec)
+	}
+	if err == nil {
+		// a line need only have code in one of the classes
+		var noCode error
+		for _, class := range loaded {
+			err = m.install(d, breakpoint, class.typeTag, class.classID)
+			if errors.Is(err, ErrNoCodeAtLine) {
+				noCode, err = err, nil
+				continue
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil && noCode != nil && len(breakpoint.requestIDs) == 0 {
+			err = noCode
+		}
+	}
+	if err != nil {
+		m.uninstall(d, breakpoint)
+		for _, prepare := range prepares {
+			m.release(d, prepare)
+		}
+		return nil, err
+	}
+
+	m.nextID++
+	for _, prepare := range prepares {
+		prepare.breakpoints = append(prepare.breakpoints, breakpoint)
+	}
+	m.breakpoints = append(m.breakpoints, breakpoint)
+	return breakpoint, nil
+}
+
+// classPatterns returns the class name patterns whose ClassPrepare
+// events a breakpoint is installed on: the class itself, and for a
+// line its nested classes too
+func classPatterns(spec BreakpointSpec) []string {
+	if spec.MethodName != "" {
+		return []string{spec.ClassName}
+	}
+	return []string{spec.ClassName, spec.ClassName + "$*"}
+}
+
+type loadedClass struct {
+	typeTag basetypes.JWDPTypeTag
+	classID basetypes.JWDPRefTypeID
+}
+
+// loadedClasses returns the prepared classes a breakpoint is to be
+// installed in: every loaded copy of its class, and for a line the
+// nested classes loaded so far
+func (d *debuggercore) loadedClasses(spec BreakpointSpec) ([]loadedClass, error) {
+	signature := common.ClassNameToSignature(spec.ClassName)
+	bySignature, err := d.ClassesBySignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []loadedClass
+	for _, class := range bySignature.Classes {
+		if class.Status&vm.AllClassClassStatusPrepared != 0 {
+			loaded = append(loaded, loadedClass{class.RefTypeTag, class.ReferenceTypeID})
+		}
+	}
+	if spec.MethodName != "" {
+		return loaded, nil
+	}
+	all, err := d.AllClasses()
+	if err != nil {
+		return nil, err
+	}
+	nestedPrefix := strings.TrimSuffix(signature, ";") + "$"
+	for _, class := range all.Classes {
+		if class.Status&vm.AllClassClassStatusPrepared != 0 && strings.HasPrefix(class.Signature.String(), nestedPrefix) {
+			loaded = append(loaded, loadedClass{class.RefTypeTag, class.ReferenceTypeID})
+		}
+	}
+	return loaded, nil
+}
+
+func (m *breakpointManager) remove(d *debuggercore, breakpoint *Breakpoint) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	idx := -1
+	for i, b := range m.breakpoints {
+		if b == breakpoint {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("unknown breakpoint %d", breakpoint.ID)
+	}
+	m.breakpoints = append(m.breakpoints[:idx], m.breakpoints[idx+1:]...)
+
+	err := m.uninstall(d, breakpoint)
+	for _, pattern := range classPatterns(breakpoint.Spec) {
+		prepare := m.prepares[pattern]
+		for i, b := range prepare.breakpoints {
+			if b == breakpoint {
+				prepare.breakpoints = append(prepare.breakpoints[:i], prepare.breakpoints[i+1:]...)
+				break
+			}
+		}
+		if releaseErr := m.release(d, prepare); err == nil {
+			err = releaseErr
+		}
+	}
+	return err
+}
+
+// classPrepare returns the ClassPrepare request for a class name
+// pattern, setting it if this is the first breakpoint to need it
+func (m *breakpointManager) classPrepare(d *debuggercore, pattern string) (*classPrepare, error) {
+	if prepare, ok := m.prepares[pattern]; ok {
+		return prepare, nil
+	}
+	if m.subscription == nil {
+		m.subscription = m.core.Subscribe(event.KindClassPrepare)
+		go m.run(m.subscription)
+	}
+	setCommandData := eventrequest.New(event.KindClassPrepare, event.SuspendPolicyEventThread).
+		ClassMatch(pattern)
+	requestID, err := d.EventRequestCommands().Set(setCommandData)
+	if err != nil {
+		return nil, fmt.Errorf("deferring breakpoint in %s: %w", pattern, err)
+	}
+	prepare := &classPrepare{pattern: pattern, requestID: requestID}
+	m.prepares[pattern] = prepare
+	m.requests[requestID] = prepare
+	return prepare, nil
+}
+
+// catch sets an exception breakpoint in every loaded copy of its
+// exception class, and defers it for copies prepared later
+func (m *breakpointManager) catch(d *debuggercore, breakpoint *ExceptionBreakpoint) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// as in add, the ClassPrepare request goes in first
+	className := breakpoint.Spec.ExceptionClass
+	prepare, err := m.classPrepare(d, className)
+	if err != nil {
+		return err
+	}
+	loaded, err := d.ClassesBySignature(common.ClassNameToSignature(className))
+	if err == nil {
+		for _, class := range loaded.Classes {
+			if class.Status&vm.AllClassClassStatusPrepared == 0 {
+				continue
+			}
+			if err = d.catchException(breakpoint, class.ReferenceTypeID); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		m.release(d, prepare)
+		return err
+	}
+	prepare.exceptions = append(prepare.exceptions, breakpoint)
+	return nil
+}
+
+// uncatch stops setting an exception breakpoint in classes as they are
+// prepared
+func (m *breakpointManager) uncatch(d *debuggercore, breakpoint *ExceptionBreakpoint) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	prepare, ok := m.prepares[breakpoint.Spec.ExceptionClass]
+	if !ok {
+		return nil
+	}
+	for i, b := range prepare.exceptions {
+		if b == breakpoint {
+			prepare.exceptions = append(prepare.exceptions[:i], prepare.exceptions[i+1:]...)
+			return m.release(d, prepare)
+		}
+	}
+	return nil
+}
+
+// release clears the ClassPrepare request once no breakpoint needs it.
+// It stays in requests, as events for it may already be in flight and
+// their threads still need resuming
+func (m *breakpointManager) release(d *debuggercore, prepare *classPrepare) error {
+	if len(prepare.breakpoints) > 0 || len(prepare.exceptions) > 0 {
+		return nil
+	}
+	delete(m.prepares, prepare.pattern)
+	return d.EventRequestCommands().Clear(event.KindClassPrepare, prepare.requestID)
+}
+
+// install resolves the breakpoint in a loaded class and sets an event
+// request at each location
+func (m *breakpointManager) install(d *debuggercore, breakpoint *Breakpoint, typeTag basetypes.JWDPTypeTag, classID basetypes.JWDPRefTypeID) error {
+	breakpoint.mutex.Lock()
+	defer breakpoint.mutex.Unlock()
+	if _, ok := breakpoint.classes[classID]; ok {
+		return nil
+	}
+	locations, err := d.breakpointLocations(breakpoint.Spec, typeTag, classID)
+	if err != nil {
+		return err
+	}
+	for _, location := range locations {
+		setCommandData := eventrequest.New(event.KindBreakpoint, breakpoint.SuspendPolicy).
+			LocationOnly(location)
+		requestID, err := d.EventRequestCommands().Set(setCommandData)
+		if err != nil {
+			return fmt.Errorf("setting %s: %w", breakpoint.Spec, err)
+		}
+		breakpoint.locations = append(breakpoint.locations, location)
+		breakpoint.requestIDs = append(breakpoint.requestIDs, requestID)
+	}
+	breakpoint.classes[classID] = struct{}{}
+	return nil
+}
+
+// uninstall clears every event request the breakpoint has set
+func (m *breakpointManager) uninstall(d *debuggercore, breakpoint *Breakpoint) error {
+	breakpoint.mutex.Lock()
+	defer breakpoint.mutex.Unlock()
+	var firstErr error
+	for _, requestID := range breakpoint.requestIDs {
+		err := d.EventRequestCommands().Clear(event.KindBreakpoint, requestID)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	breakpoint.classes = make(map[basetypes.JWDPRefTypeID]struct{})
+	breakpoint.locations = nil
+	breakpoint.requestIDs = nil
+	return firstErr
+}
+
+// run installs deferred breakpoints and exception breakpoints as their
+// classes are prepared, then resumes what the class prepare suspended.
+// That is only done when every event of the composite is one of ours,
+// as otherwise its other events are left for their own subscribers to
+// resume
+func (m *breakpointManager) run(subscription EventSubscription) {
+	logger := m.core.jdwpsession.Logger()
+	for ev := range subscription.Events() {
+		prepared, ok := ev.Event.(*event.ClassPrepare)
+		if !ok {
+			continue
+		}
+		m.mutex.Lock()
+		prepare, ours := m.requests[ev.RequestID]
+		if ours {
+			for _, breakpoint := range prepare.breakpoints {
+				err := m.install(m.core, breakpoint, prepared.RefTypeTag, prepared.TypeID)
+				if errors.Is(err, ErrNoCodeAtLine) && breakpoint.Resolved() {
+					// the line is in another of the classes
+					continue
+				}
+				breakpoint.mutex.Lock()
+				breakpoint.err = err
+				breakpoint.mutex.Unlock()
+				if err != nil {
+					logger.Warn("resolving deferred breakpoint", "breakpoint", breakpoint.Spec.String(), "error", err)
+				}
+			}
+			for _, exception := range prepare.exceptions {
+				if err := m.core.catchException(exception, prepared.TypeID); err != nil {
+					logger.Warn("setting deferred exception breakpoint", "exception", exception.Spec.ExceptionClass, "error", err)
+				}
+			}
+		}
+		resume := ours && m.ownsComposite(ev)
+		m.mutex.Unlock()
+
+		if !resume {
+			continue
+		}
+		if err := m.core.resumeSuspended(ev.SuspendPolicy, prepared.Thread); err != nil {
+			logger.Warn("resuming after class prepare", "thread", prepared.Thread, "error", err)
+		}
+	}
+}
+
+// ownsComposite reports whether ev is the last event of its composite
+// and every event of the composite is one of the manager's ClassPrepare
+// requests, so the composite is resumed once, after all are handled
+func (m *breakpointManager) ownsComposite(ev *Event) bool {
+	if len(ev.Composite) == 0 {
+		return true
+	}
+	if ev.Composite[len(ev.Composite)-1] != ev {
+		return false
+	}
+	for _, other := range ev.Composite {
+		if other.Kind != event.KindClassPrepare {
+			return false
+		}
+		if _, ok := m.requests[other.RequestID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// breakpointLocations resolves a spec to locations in a loaded class:
+// the first code index of the line in each method that has code at the
+// line, or the start of the named method
+func (d *debuggercore) breakpointLocations(spec BreakpointSpec, typeTag basetypes.JWDPTypeTag, classID basetypes.JWDPRefTypeID) ([]common.Location, error) {
+	methods, err := d.ReferenceTypeCommands().Methods(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.MethodName != "" {
+		method, err := matchMethod(methods, spec.MethodName, spec.MethodSignature)
+		if err != nil {
+			return nil, err
+		}
+		if method == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, spec)
+		}
+		if method.ModBits&(accNative|accAbstract) != 0 {
+			return nil, fmt.Errorf("%s has no code", spec)
+		}
+		return []common.Location{{
+			TypeTag:  typeTag,
+			ClassID:  classID,
+			MethodID: method.MethodID,
+		}}, nil
+	}
+
+	var locations []common.Location
+	for _, method := range methods.Declared {
+		lineTable, err := d.lineTable(classID, method.MethodID)
+		if err != nil {
+			return nil, err
+		}
+		if lineTable == nil {
+			continue
+		}
+		if indices := lineTable.IndicesForLine(spec.Line); len(indices) > 0 {
+			locations = append(locations, common.Location{
+				TypeTag:  typeTag,
+				ClassID:  classID,
+				MethodID: method.MethodID,
+				Index:    uint64(indices[0]),
+			})
+		}
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoCodeAtLine, spec)
+	}
+	return locations, nil
+}
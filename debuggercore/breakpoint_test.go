@@ -0,0 +1,395 @@
+package debuggercore_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/method"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const (
+	barClass       = 0x40
+	barSignature   = "Lcom/foo/Bar;"
+	innerClass     = 0x41
+	innerSignature = "Lcom/foo/Bar$Inner;"
+)
+
+// fakeVM serves the commands the breakpoint manager uses. com.foo.Bar
+// has run(), with code at lines 10 and 11, and stop(), at line 20; its
+// nested class com.foo.Bar$Inner has inner(), at line 30. Neither is
+// loaded until load or loadInner is called
+type fakeVM struct {
+	srv      *jdwptest.Server
+	requests *fakeEventRequests
+
+	mutex  sync.Mutex
+	loaded map[uint64]string
+}
+
+func startFakeVM(t *testing.T) (debuggercore.DebuggerCore, *fakeVM) {
+	fake := &fakeVM{loaded: make(map[uint64]string)}
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		fake.srv = srv
+		fake.requests = handleEventRequests(srv)
+		handleClasses(srv, map[uint64]*fakeClass{
+			barClass: {
+				modifiers: accPublic,
+				methods: []reftype.Method{
+					fakeMethod(1, "run", "()V", accPublic),
+					fakeMethod(2, "stop", "()V", accPublic),
+				},
+			},
+			innerClass: {
+				methods: []reftype.Method{
+					fakeMethod(3, "inner", "()V", 0),
+				},
+			},
+		})
+		srv.Handle(vm.ClassesBySignatureCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData vm.ClassesBySignatureCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+			}
+			reply := &vm.ClassesBySignatureReply{}
+			fake.mutex.Lock()
+			defer fake.mutex.Unlock()
+			for classID, signature := range fake.loaded {
+				if signature == commandData.Signature.String() {
+					reply.NumClasses++
+					reply.Classes = append(reply.Classes, vm.ClassBySignature{
+						RefTypeTag:      basetypes.JWDPTypeTagClass,
+						ReferenceTypeID: basetypes.JWDPRefTypeID{RefTypeID: classID},
+						Status:          vm.AllClassClassStatusVerified | vm.AllClassClassStatusPrepared,
+					})
+				}
+			}
+			return srv.StructReply(reply)
+		})
+		srv.Handle(vm.AllClassesCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			reply := &vm.AllClassReply{}
+			fake.mutex.Lock()
+			defer fake.mutex.Unlock()
+			for classID, signature := range fake.loaded {
+				reply.NumClasses++
+				reply.Classes = append(reply.Classes, vm.AllClassClass{
+					RefTypeTag:      basetypes.JWDPTypeTagClass,
+					ReferenceTypeID: basetypes.JWDPRefTypeID{RefTypeID: classID},
+					Signature:       basetypes.NewJDWPString(signature),
+					Status:          vm.AllClassClassStatusVerified | vm.AllClassClassStatusPrepared,
+				})
+			}
+			return srv.StructReply(reply)
+		})
+		srv.Handle(method.LineTableCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData method.LineTableCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+			}
+			lines := map[uint64][]method.Line{
+				1: {{LineCodeIndex: 0, LineNumber: 10}, {LineCodeIndex: 4, LineNumber: 11}},
+				2: {{LineCodeIndex: 0, LineNumber: 20}},
+				3: {{LineCodeIndex: 0, LineNumber: 30}},
+			}[commandData.MethodID.MethodID]
+			return srv.StructReply(&method.LineTableReply{Start: 0, End: 8, NumLines: int32(len(lines)), Lines: lines})
+		})
+		srv.HandleData(thread.ResumeCommand, nil)
+		srv.HandleData(vm.ResumeCommand, nil)
+	})
+	return core, fake
+}
+
+// load loads com.foo.Bar, returning the ClassPrepare event for it that
+// a request would report
+func (f *fakeVM) load(requestID int32, threadID uint64) *event.ClassPrepare {
+	return f.loadClass(barClass, barSignature, requestID, threadID)
+}
+
+// loadInner loads com.foo.Bar$Inner, as load does com.foo.Bar
+func (f *fakeVM) loadInner(requestID int32, threadID uint64) *event.ClassPrepare {
+	return f.loadClass(innerClass, innerSignature, requestID, threadID)
+}
+
+func (f *fakeVM) loadClass(classID uint64, signature string, requestID int32, threadID uint64) *event.ClassPrepare {
+	f.mutex.Lock()
+	f.loaded[classID] = signature
+	f.mutex.Unlock()
+	return &event.ClassPrepare{
+		RequestID:  requestID,
+		Thread:     common.ThreadID{ObjectID: threadID},
+		RefTypeTag: basetypes.JWDPTypeTagClass,
+		TypeID:     basetypes.JWDPRefTypeID{RefTypeID: classID},
+		Signature:  basetypes.NewJDWPString(signature),
+		Status:     vm.AllClassClassStatusVerified | vm.AllClassClassStatusPrepared,
+	}
+}
+
+func (f *fakeVM) threadResumes() []uint64 {
+	var threads []uint64
+	for _, commandPacket := range received(f.srv, thread.ResumeCommand) {
+		var commandData thread.ResumeCommandData
+		f.srv.UnpackCommand(commandPacket, &commandData)
+		threads = append(threads, commandData.ThreadID.ObjectID)
+	}
+	return threads
+}
+
+// classPrepareRequest returns the active ClassPrepare request for a
+// class name pattern
+func (f *fakeVM) classPrepareRequest(t *testing.T, pattern string) int32 {
+	t.Helper()
+	for _, requestID := range f.requests.active(event.KindClassPrepare) {
+		request := f.requests.get(requestID)
+		if len(request.Modifiers) != 1 {
+			t.Fatalf("ClassPrepare request: %v", request)
+		}
+		if match, ok := request.Modifiers[0].(*eventrequest.ClassMatchModifier); ok && match.ClassPattern.String() == pattern {
+			return requestID
+		}
+	}
+	t.Fatalf("no ClassPrepare request for %v", pattern)
+	return 0
+}
+
+func TestBreakpointDeferredResolution(t *testing.T) {
+	core, fake := startFakeVM(t)
+	breakpoint, err := core.BreakpointCommands().Add("com.foo.Bar:11", event.SuspendPolicyAll)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if breakpoint.Resolved() {
+		t.Fatal("breakpoint resolved before its class is loaded")
+	}
+	prepareID := fake.classPrepareRequest(t, "com.foo.Bar")
+	if prepare := fake.requests.get(prepareID); prepare.SuspendPolicy != event.SuspendPolicyEventThread {
+		t.Fatalf("ClassPrepare request: %v", prepare)
+	}
+	// a line may be in a nested class
+	nestedID := fake.classPrepareRequest(t, "com.foo.Bar$*")
+	if prepare := fake.requests.get(nestedID); prepare.SuspendPolicy != event.SuspendPolicyEventThread {
+		t.Fatalf("ClassPrepare request: %v", prepare)
+	}
+
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(prepareID, 7))
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) > 0 })
+
+	if !breakpoint.Resolved() || breakpoint.Err() != nil {
+		t.Fatalf("breakpoint not resolved: %v", breakpoint)
+	}
+	want := common.Location{
+		TypeTag:  basetypes.JWDPTypeTagClass,
+		ClassID:  basetypes.JWDPRefTypeID{RefTypeID: barClass},
+		MethodID: basetypes.JWDPMethodID{MethodID: 1},
+		Index:    4,
+	}
+	if locations := breakpoint.Locations(); len(locations) != 1 || locations[0] != want {
+		t.Fatalf("Locations: got %v, want %v", locations, want)
+	}
+	requestIDs := breakpoint.RequestIDs()
+	if len(requestIDs) != 1 {
+		t.Fatalf("RequestIDs: got %v", requestIDs)
+	}
+	request := fake.requests.get(requestIDs[0])
+	if request.EventKind != event.KindBreakpoint || request.SuspendPolicy != event.SuspendPolicyAll ||
+		request.Modifiers[0].(*eventrequest.LocationOnlyModifier).Location != want {
+		t.Fatalf("breakpoint request: %v", request)
+	}
+	if found, ok := core.BreakpointCommands().Find(requestIDs[0]); !ok || found != breakpoint {
+		t.Fatalf("Find: got %v", found)
+	}
+	if threads := fake.threadResumes(); len(threads) != 1 || threads[0] != 7 {
+		t.Fatalf("resumed threads %v, want [7]", threads)
+	}
+	if resumes := received(fake.srv, vm.ResumeCommand); len(resumes) != 0 {
+		t.Fatalf("VM resumed %v times", len(resumes))
+	}
+}
+
+func TestBreakpointResumesOnlyItsOwnComposites(t *testing.T) {
+	core, fake := startFakeVM(t)
+	breakpoint, err := core.BreakpointCommands().Add("com.foo.Bar.run", event.SuspendPolicyAll)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	prepareID := fake.classPrepareRequest(t, "com.foo.Bar")
+
+	// grouped with someone else's event, the composite is theirs to
+	// resume, though the breakpoint is still installed
+	fake.srv.SendEvents(event.SuspendPolicyAll,
+		fake.load(prepareID, 7),
+		&event.ThreadStart{RequestID: 99, Thread: common.ThreadID{ObjectID: 8}})
+	waitFor(t, "the breakpoint to resolve", breakpoint.Resolved)
+
+	// a composite of only our events suspending everything is resumed
+	// once, by resuming the VM
+	fake.srv.SendEvents(event.SuspendPolicyAll, fake.load(prepareID, 7), fake.load(prepareID, 9))
+	waitFor(t, "the VM to be resumed", func() bool { return len(received(fake.srv, vm.ResumeCommand)) > 0 })
+
+	// and one suspending the event thread resumes just that thread
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(prepareID, 10))
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) > 0 })
+
+	if resumes := received(fake.srv, vm.ResumeCommand); len(resumes) != 1 {
+		t.Fatalf("VM resumed %v times, want 1", len(resumes))
+	}
+	if threads := fake.threadResumes(); len(threads) != 1 || threads[0] != 10 {
+		t.Fatalf("resumed threads %v, want [10]", threads)
+	}
+}
+
+func TestBreakpointRemoveReleasesClassPrepare(t *testing.T) {
+	core, fake := startFakeVM(t)
+	breakpoints := core.BreakpointCommands()
+	first, err := breakpoints.Add("com.foo.Bar:10", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second, err := breakpoints.Add("com.foo.Bar:20", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// the two breakpoints share ClassPrepare requests
+	prepareID := fake.classPrepareRequest(t, "com.foo.Bar")
+	if got := fake.requests.active(event.KindClassPrepare); len(got) != 2 {
+		t.Fatalf("got ClassPrepare requests %v, want 2", got)
+	}
+
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(prepareID, 7))
+	waitFor(t, "the breakpoints to resolve", func() bool { return first.Resolved() && second.Resolved() })
+	if got := fake.requests.active(event.KindBreakpoint); len(got) != 2 {
+		t.Fatalf("got breakpoint requests %v, want 2", got)
+	}
+
+	if err := breakpoints.Remove(first); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := fake.requests.active(event.KindBreakpoint); len(got) != 1 || got[0] != second.RequestIDs()[0] {
+		t.Fatalf("after Remove: got breakpoint requests %v", got)
+	}
+	if got := fake.requests.active(event.KindClassPrepare); len(got) != 2 {
+		t.Fatal("ClassPrepare requests released while a breakpoint still needs them")
+	}
+
+	if err := breakpoints.Remove(second); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := fake.requests.active(event.KindBreakpoint); len(got) != 0 {
+		t.Fatalf("after Remove: got breakpoint requests %v", got)
+	}
+	if got := fake.requests.active(event.KindClassPrepare); len(got) != 0 {
+		t.Fatal("ClassPrepare requests not released with the last breakpoint")
+	}
+	if got := breakpoints.List(); len(got) != 0 {
+		t.Fatalf("List: got %v", got)
+	}
+	if err := breakpoints.Remove(second); err == nil {
+		t.Fatal("Remove: expected error removing a breakpoint twice")
+	}
+
+	// a class prepare already in flight is still resumed
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(prepareID, 8))
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) == 2 })
+}
+
+func TestBreakpointNoCodeAtLineOnLoad(t *testing.T) {
+	core, fake := startFakeVM(t)
+	breakpoint, err := core.BreakpointCommands().Add("com.foo.Bar:15", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if breakpoint.Err() != nil {
+		t.Fatalf("Err before load: %v", breakpoint.Err())
+	}
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(fake.classPrepareRequest(t, "com.foo.Bar"), 7))
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) > 0 })
+
+	if !errors.Is(breakpoint.Err(), debuggercore.ErrNoCodeAtLine) {
+		t.Fatalf("Err: got %v, want ErrNoCodeAtLine", breakpoint.Err())
+	}
+	if breakpoint.Resolved() {
+		t.Fatal("breakpoint resolved with no code at its line")
+	}
+
+	// once the class is loaded, Add fails straight away
+	_, err = core.BreakpointCommands().Add("com.foo.Bar:16", event.SuspendPolicyEventThread)
+	if !errors.Is(err, debuggercore.ErrNoCodeAtLine) {
+		t.Fatalf("Add: got %v, want ErrNoCodeAtLine", err)
+	}
+	if got := core.BreakpointCommands().List(); len(got) != 1 {
+		t.Fatalf("List: got %v", got)
+	}
+}
+
+func TestBreakpointLineInNestedClass(t *testing.T) {
+	core, fake := startFakeVM(t)
+	breakpoint, err := core.BreakpointCommands().Add("com.foo.Bar:30", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// the outer class has no code at the line
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.load(fake.classPrepareRequest(t, "com.foo.Bar"), 7))
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) == 1 })
+	if breakpoint.Resolved() || !errors.Is(breakpoint.Err(), debuggercore.ErrNoCodeAtLine) {
+		t.Fatalf("after loading com.foo.Bar: %v", breakpoint)
+	}
+
+	// but the nested one does
+	fake.srv.SendEvents(event.SuspendPolicyEventThread, fake.loadInner(fake.classPrepareRequest(t, "com.foo.Bar$*"), 8))
+	waitFor(t, "the breakpoint to resolve", breakpoint.Resolved)
+	if breakpoint.Err() != nil {
+		t.Fatalf("Err: %v", breakpoint.Err())
+	}
+	want := common.Location{
+		TypeTag:  basetypes.JWDPTypeTagClass,
+		ClassID:  basetypes.JWDPRefTypeID{RefTypeID: innerClass},
+		MethodID: basetypes.JWDPMethodID{MethodID: 3},
+	}
+	if locations := breakpoint.Locations(); len(locations) != 1 || locations[0] != want {
+		t.Fatalf("Locations: got %v, want %v", locations, want)
+	}
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(fake.threadResumes()) == 2 })
+}
+
+func TestBreakpointLineInLoadedNestedClass(t *testing.T) {
+	core, fake := startFakeVM(t)
+	fake.load(0, 0)
+	fake.loadInner(0, 0)
+
+	inner, err := core.BreakpointCommands().Add("com.foo.Bar:30", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if locations := inner.Locations(); len(locations) != 1 || locations[0].ClassID.RefTypeID != innerClass {
+		t.Fatalf("Locations: got %v, want one in com.foo.Bar$Inner", locations)
+	}
+	// a line in the outer class need not be in the nested one too
+	outer, err := core.BreakpointCommands().Add("com.foo.Bar:11", event.SuspendPolicyEventThread)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if locations := outer.Locations(); len(locations) != 1 || locations[0].ClassID.RefTypeID != barClass {
+		t.Fatalf("Locations: got %v, want one in com.foo.Bar", locations)
+	}
+	if _, err := core.BreakpointCommands().Add("com.foo.Bar:40", event.SuspendPolicyEventThread); !errors.Is(err, debuggercore.ErrNoCodeAtLine) {
+		t.Fatalf("Add: got %v, want ErrNoCodeAtLine", err)
+	}
+
+	// a method is only looked for in the class named
+	if _, err := core.BreakpointCommands().Add("com.foo.Bar.inner", event.SuspendPolicyEventThread); !errors.Is(err, debuggercore.ErrMethodNotFound) {
+		t.Fatalf("Add: got %v, want ErrMethodNotFound", err)
+	}
+	if got := fake.requests.active(event.KindClassPrepare); len(got) != 2 {
+		t.Fatalf("got ClassPrepare requests %v, want 2", got)
+	}
+}
@@ -0,0 +1,103 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/classtype"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/interfacetype"
+)
+
+// ClassTypeCommands expose the ClassType commands
+type ClassTypeCommands interface {
+	// Superclass returns a null ID for java.lang.Object
+	Superclass(basetypes.JWDPRefTypeID) (basetypes.JWDPRefTypeID, error)
+	// InvokeMethod runs a static method of clazz, or one of its
+	// superclasses, in thread, which must be suspended by an event
+	InvokeMethod(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*classtype.InvokeMethodReply, error)
+	// NewInstance runs the constructor methodID of clazz in thread
+	NewInstance(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*classtype.NewInstanceReply, error)
+}
+
+// InterfaceTypeCommands expose the InterfaceType commands
+type InterfaceTypeCommands interface {
+	// InvokeMethod runs a static method of clazz in thread, which must
+	// be suspended by an event
+	InvokeMethod(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*interfacetype.InvokeMethodReply, error)
+}
+
+type classTypeCommands struct {
+	*debuggercore
+}
+
+func (c *classTypeCommands) Superclass(clazz basetypes.JWDPRefTypeID) (basetypes.JWDPRefTypeID, error) {
+	superclassCommandData := &classtype.SuperclassCommandData{
+		Clazz: clazz,
+	}
+	var superclassReply classtype.SuperclassReply
+	err := c.processCommand(classtype.SuperclassCommand, superclassCommandData, &superclassReply)
+	if err != nil {
+		return basetypes.JWDPRefTypeID{}, err
+	}
+	return superclassReply.Superclass, nil
+}
+
+func (c *classTypeCommands) InvokeMethod(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*classtype.InvokeMethodReply, error) {
+	invokeMethodCommandData := &classtype.InvokeMethodCommandData{
+		Clazz:        clazz,
+		Thread:       thread,
+		MethodID:     methodID,
+		NumArguments: int32(len(arguments)),
+		Arguments:    arguments,
+		Options:      options,
+	}
+	var invokeMethodReply classtype.InvokeMethodReply
+	err := c.processCommand(classtype.InvokeMethodCommand, invokeMethodCommandData, &invokeMethodReply)
+	if err != nil {
+		return nil, err
+	}
+	return &invokeMethodReply, nil
+}
+
+func (c *classTypeCommands) NewInstance(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*classtype.NewInstanceReply, error) {
+	newInstanceCommandData := &classtype.NewInstanceCommandData{
+		Clazz:        clazz,
+		Thread:       thread,
+		MethodID:     methodID,
+		NumArguments: int32(len(arguments)),
+		Arguments:    arguments,
+		Options:      options,
+	}
+	var newInstanceReply classtype.NewInstanceReply
+	err := c.processCommand(classtype.NewInstanceCommand, newInstanceCommandData, &newInstanceReply)
+	if err != nil {
+		return nil, err
+	}
+	return &newInstanceReply, nil
+}
+
+type interfaceTypeCommands struct {
+	*debuggercore
+}
+
+func (i *interfaceTypeCommands) InvokeMethod(clazz basetypes.JWDPRefTypeID, thread common.ThreadID, methodID basetypes.JWDPMethodID,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*interfacetype.InvokeMethodReply, error) {
+	invokeMethodCommandData := &interfacetype.InvokeMethodCommandData{
+		Clazz:        clazz,
+		Thread:       thread,
+		MethodID:     methodID,
+		NumArguments: int32(len(arguments)),
+		Arguments:    arguments,
+		Options:      options,
+	}
+	var invokeMethodReply interfacetype.InvokeMethodReply
+	err := i.processCommand(interfacetype.InvokeMethodCommand, invokeMethodCommandData, &invokeMethodReply)
+	if err != nil {
+		return nil, err
+	}
+	return &invokeMethodReply, nil
+}
@@ -1,32 +1,86 @@
 package debuggercore
 
 import (
-	"encoding/binary"
-	"errors"
+	"context"
 
 	"github.com/jquirke/jdwpgo/api/jdwp"
 	"github.com/jquirke/jdwpgo/jdwpsession"
-	"gopkg.in/restruct.v1"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/vm"
 )
 
 // DebuggerCore represents an instance of the debugger core
 type DebuggerCore interface {
 	VMCommands() VMCommands
 	ThreadCommands() ThreadCommands
+	EventCommands() EventCommands
+	EventRequestCommands() EventRequestCommands
+	ReferenceTypeCommands() ReferenceTypeCommands
+	ObjectCommands() ObjectCommands
+	StringReferenceCommands() StringReferenceCommands
+	ClassTypeCommands() ClassTypeCommands
+	InterfaceTypeCommands() InterfaceTypeCommands
+	InvokeCommands() InvokeCommands
+	FrameCommands() FrameCommands
+	MethodCommands() MethodCommands
+	BreakpointCommands() BreakpointCommands
+	ExceptionCommands() ExceptionCommands
+	// WithContext returns a DebuggerCore whose commands are all bound
+	// to ctx; a command is abandoned when ctx is cancelled or times out
+	WithContext(ctx context.Context) DebuggerCore
 }
 
 type debuggercore struct {
+	ctx         context.Context
 	jdwpsession jdwpsession.Session
+	idSizes     basetypes.IDSizes
+	events      *eventDispatcher
+	breakpoints *breakpointManager
 }
 
 // NewFromJWDPSession creates a new instance of a debugger core
-// attached to a JWDP session
-func NewFromJWDPSession(session jdwpsession.Session) DebuggerCore {
+// attached to a started JWDP session. The VM is queried for its
+// ID sizes, which are used to serialise all subsequent commands, and
+// the core takes over reading the session's JVM command channel to
+// decode events
+func NewFromJWDPSession(session jdwpsession.Session) (DebuggerCore, error) {
+	return NewFromJWDPSessionContext(context.Background(), session)
+}
+
+// NewFromJWDPSessionContext is NewFromJWDPSession with ctx bounding
+// the initial ID sizes query. ctx is not retained
+func NewFromJWDPSessionContext(ctx context.Context, session jdwpsession.Session) (DebuggerCore, error) {
 	core := &debuggercore{
+		ctx:         context.Background(),
 		jdwpsession: session,
+		idSizes:     basetypes.DefaultIDSizes(),
+		events:      newEventDispatcher(),
+	}
+
+	var idSizesReply vm.IDSizesReply
+	err := core.processCommandContext(ctx, vm.IDSizesCommand, nil, &idSizesReply)
+	if err != nil {
+		return nil, err
+	}
+	idSizes := idSizesReply.IDSizes()
+	if err := idSizes.Validate(); err != nil {
+		return nil, err
 	}
+	core.idSizes = idSizes
+	core.breakpoints = newBreakpointManager(core)
 
-	return core
+	go core.events.run(session.JvmCommandPacketChannel(), idSizes, session.Logger(), core.resumeSuspended)
+
+	return core, nil
+}
+
+func (d *debuggercore) WithContext(ctx context.Context) DebuggerCore {
+	if ctx == nil {
+		panic("nil context")
+	}
+	core := *d
+	core.ctx = ctx
+	return &core
 }
 
 func (d *debuggercore) VMCommands() VMCommands {
@@ -34,33 +88,83 @@ func (d *debuggercore) VMCommands() VMCommands {
 }
 
 func (d *debuggercore) ThreadCommands() ThreadCommands {
+	return &threadCommands{d}
+}
+
+func (d *debuggercore) EventCommands() EventCommands {
 	return d
 }
 
+func (d *debuggercore) EventRequestCommands() EventRequestCommands {
+	return &eventRequestCommands{d}
+}
+
+func (d *debuggercore) ReferenceTypeCommands() ReferenceTypeCommands {
+	return &referenceTypeCommands{d}
+}
+
+func (d *debuggercore) ObjectCommands() ObjectCommands {
+	return &objectCommands{d}
+}
+
+func (d *debuggercore) StringReferenceCommands() StringReferenceCommands {
+	return &stringReferenceCommands{d}
+}
+
+func (d *debuggercore) ClassTypeCommands() ClassTypeCommands {
+	return &classTypeCommands{d}
+}
+
+func (d *debuggercore) InterfaceTypeCommands() InterfaceTypeCommands {
+	return &interfaceTypeCommands{d}
+}
+
+func (d *debuggercore) InvokeCommands() InvokeCommands {
+	return &invokeCommands{d}
+}
+
+func (d *debuggercore) FrameCommands() FrameCommands {
+	return &frameCommands{d}
+}
+
+func (d *debuggercore) MethodCommands() MethodCommands {
+	return &methodCommands{d}
+}
+
+func (d *debuggercore) BreakpointCommands() BreakpointCommands {
+	return &breakpointCommands{d}
+}
+
+func (d *debuggercore) ExceptionCommands() ExceptionCommands {
+	return &exceptionCommands{d}
+}
+
 func (d *debuggercore) processCommand(cmd jdwp.Command, requestStruct interface{}, replyStruct interface{}) error {
+	return d.processCommandContext(d.ctx, cmd, requestStruct, replyStruct)
+}
+
+func (d *debuggercore) processCommandContext(ctx context.Context, cmd jdwp.Command, requestStruct interface{}, replyStruct interface{}) error {
 	commandPacket := &jdwpsession.CommandPacket{
 		Commandset: cmd.Commandset,
 		Command:    cmd.Command,
 	}
 	var err error
 	if cmd.HasCommandData {
-		commandPacket.Data, err = restruct.Pack(binary.BigEndian, requestStruct)
+		commandPacket.Data, err = d.idSizes.Pack(requestStruct)
 		if err != nil {
 			return err
 		}
 	}
-	// TODO implement timeout
-
-	replyCh := d.jdwpsession.SendCommand(commandPacket)
-
-	reply, ok := <-replyCh
-	if !ok {
-		return errors.New("Channel closed")
+	reply, err := d.jdwpsession.SendCommandContext(ctx, commandPacket)
+	if err != nil {
+		return err
+	}
+	if reply.Errorcode != uint16(jdwp.ErrorNone) {
+		return jdwp.NewError(jdwp.ErrorCode(reply.Errorcode), cmd)
 	}
-	// TODO handle protocol returned err
 
 	if cmd.HasReplyData {
-		err = restruct.Unpack(reply.Data, binary.BigEndian, replyStruct)
+		err = d.idSizes.Unpack(reply.Data, replyStruct)
 	}
 	return err
 }
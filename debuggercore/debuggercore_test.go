@@ -0,0 +1,295 @@
+package debuggercore_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/classtype"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const testTimeout = 5 * time.Second
+
+// startCore starts srv, after configure has scripted it, and attaches a
+// debugger core to it. Everything is torn down when the test ends
+func startCore(t *testing.T, configure func(*jdwptest.Server)) (debuggercore.DebuggerCore, *jdwptest.Server) {
+	t.Helper()
+	srv, conn := jdwptest.NewPipe()
+	if configure != nil {
+		configure(srv)
+	}
+	srv.Start()
+	session := jdwpsession.New(conn)
+	if err := session.Start(); err != nil {
+		t.Fatalf("session Start: %v", err)
+	}
+	t.Cleanup(func() {
+		session.Stop()
+		srv.Close()
+		if err := srv.Err(); err != nil {
+			t.Errorf("server: %v", err)
+		}
+	})
+	core, err := debuggercore.NewFromJWDPSession(session)
+	if err != nil {
+		t.Fatalf("NewFromJWDPSession: %v", err)
+	}
+	return core, srv
+}
+
+// waitFor polls until cond holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// received returns the commands of one kind the server has received
+func received(srv *jdwptest.Server, cmd jdwp.Command) []*jdwpsession.CommandPacket {
+	var matching []*jdwpsession.CommandPacket
+	for _, commandPacket := range srv.Received() {
+		if commandPacket.Commandset == cmd.Commandset && commandPacket.Command == cmd.Command {
+			matching = append(matching, commandPacket)
+		}
+	}
+	return matching
+}
+
+// fakeEventRequests answers EventRequest Set and Clear, keeping track
+// of the requests that are set
+type fakeEventRequests struct {
+	mutex    sync.Mutex
+	nextID   int32
+	requests map[int32]*eventrequest.SetCommandData
+}
+
+func handleEventRequests(srv *jdwptest.Server) *fakeEventRequests {
+	f := &fakeEventRequests{requests: make(map[int32]*eventrequest.SetCommandData)}
+	srv.Handle(eventrequest.SetCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		setCommandData, err := eventrequest.DecodeSetCommandData(srv.IDSizes, commandPacket.Data)
+		if err != nil {
+			return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+		}
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.nextID++
+		f.requests[f.nextID] = setCommandData
+		return srv.StructReply(&eventrequest.SetReply{RequestID: f.nextID})
+	})
+	srv.Handle(eventrequest.ClearCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		var commandData eventrequest.ClearCommandData
+		if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+			return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+		}
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		if request, ok := f.requests[commandData.RequestID]; !ok || request.EventKind != commandData.EventKind {
+			return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInvalidEventType)}
+		}
+		delete(f.requests, commandData.RequestID)
+		return &jdwpsession.ReplyPacket{}
+	})
+	return f
+}
+
+// active returns the IDs of the requests of a kind that are set, in
+// the order they were set
+func (f *fakeEventRequests) active(kind event.Kind) []int32 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var ids []int32
+	for id := int32(1); id <= f.nextID; id++ {
+		if request, ok := f.requests[id]; ok && request.EventKind == kind {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (f *fakeEventRequests) get(requestID int32) *eventrequest.SetCommandData {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.requests[requestID]
+}
+
+// fakeClass is a class or interface served by handleClasses
+type fakeClass struct {
+	superclass uint64
+	interfaces []uint64
+	modifiers  int32
+	methods    []reftype.Method
+}
+
+// handleClasses answers the ReferenceType and ClassType commands that
+// describe the class hierarchy from classes, keyed by reference type ID
+func handleClasses(srv *jdwptest.Server, classes map[uint64]*fakeClass) {
+	lookup := func(commandPacket *jdwpsession.CommandPacket) (*fakeClass, *jdwpsession.ReplyPacket) {
+		// every one of these commands starts with the reference type
+		var commandData struct {
+			RefType basetypes.JWDPRefTypeID
+		}
+		if _, err := srv.IDSizes.UnpackPrefix(commandPacket.Data, &commandData); err != nil {
+			return nil, &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+		}
+		class, ok := classes[commandData.RefType.RefTypeID]
+		if !ok {
+			return nil, &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInvalidClass)}
+		}
+		return class, nil
+	}
+	srv.Handle(reftype.MethodsCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		class, errReply := lookup(commandPacket)
+		if errReply != nil {
+			return errReply
+		}
+		return srv.StructReply(&reftype.MethodsReply{NumDeclared: int32(len(class.methods)), Declared: class.methods})
+	})
+	srv.Handle(reftype.ModifiersCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		class, errReply := lookup(commandPacket)
+		if errReply != nil {
+			return errReply
+		}
+		return srv.StructReply(&reftype.ModifiersReply{ModBits: class.modifiers})
+	})
+	srv.Handle(reftype.InterfacesCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		class, errReply := lookup(commandPacket)
+		if errReply != nil {
+			return errReply
+		}
+		reply := &reftype.InterfacesReply{NumInterfaces: int32(len(class.interfaces))}
+		for _, iface := range class.interfaces {
+			reply.Interfaces = append(reply.Interfaces, basetypes.JWDPRefTypeID{RefTypeID: iface})
+		}
+		return srv.StructReply(reply)
+	})
+	srv.Handle(classtype.SuperclassCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		class, errReply := lookup(commandPacket)
+		if errReply != nil {
+			return errReply
+		}
+		return srv.StructReply(&classtype.SuperclassReply{Superclass: basetypes.JWDPRefTypeID{RefTypeID: class.superclass}})
+	})
+}
+
+func fakeMethod(methodID uint64, name string, signature string, modBits int32) reftype.Method {
+	return reftype.Method{
+		MethodID:  basetypes.JWDPMethodID{MethodID: methodID},
+		Name:      basetypes.NewJDWPString(name),
+		Signature: basetypes.NewJDWPString(signature),
+		ModBits:   modBits,
+	}
+}
+
+func TestIDSizesNegotiated(t *testing.T) {
+	idSizes := basetypes.IDSizes{
+		FieldIDSize:         4,
+		MethodIDSize:        4,
+		ObjectIDSize:        4,
+		ReferenceTypeIDSize: 4,
+		FrameIDSize:         4,
+	}
+	core, srv := startCore(t, func(srv *jdwptest.Server) {
+		srv.IDSizes = idSizes
+		srv.HandleStruct(vm.AllThreadsCommand, &vm.AllThreadsReply{
+			NumThreads: 2,
+			Threads:    []common.ThreadID{{ObjectID: 0x11}, {ObjectID: 0x22}},
+		})
+		srv.HandleStruct(thread.NameCommand, &thread.NameReply{ThreadName: basetypes.NewJDWPString("main")})
+	})
+
+	// replies are unpacked with 4 byte IDs
+	allThreads, err := core.VMCommands().AllThreads()
+	if err != nil {
+		t.Fatalf("AllThreads: %v", err)
+	}
+	want := []common.ThreadID{{ObjectID: 0x11}, {ObjectID: 0x22}}
+	if !reflect.DeepEqual(allThreads.Threads, want) {
+		t.Fatalf("AllThreads: got %v, want %v", allThreads.Threads, want)
+	}
+
+	// and commands are packed with them
+	name, err := core.ThreadCommands().Name(common.ThreadID{ObjectID: 0x22})
+	if err != nil {
+		t.Fatalf("Name: %v", err)
+	}
+	if name.String() != "main" {
+		t.Fatalf("Name: got %q", name.String())
+	}
+	received := srv.Received()
+	nameCommand := received[len(received)-1]
+	if wantData := []byte{0, 0, 0, 0x22}; !bytes.Equal(nameCommand.Data, wantData) {
+		t.Fatalf("Name command data: got % X, want % X", nameCommand.Data, wantData)
+	}
+}
+
+func TestUnsupportedIDSizes(t *testing.T) {
+	srv, conn := jdwptest.NewPipe()
+	srv.IDSizes.ObjectIDSize = 16
+	srv.Start()
+	defer srv.Close()
+	session := jdwpsession.New(conn)
+	if err := session.Start(); err != nil {
+		t.Fatalf("session Start: %v", err)
+	}
+	defer session.Stop()
+	_, err := debuggercore.NewFromJWDPSession(session)
+	if err == nil || !strings.Contains(err.Error(), "unsupported ObjectIDSize: 16") {
+		t.Fatalf("NewFromJWDPSession: got %v, want unsupported ObjectIDSize", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		srv.HandleError(thread.NameCommand, jdwp.ErrorInvalidThread)
+	})
+	_, err := core.ThreadCommands().Name(common.ThreadID{ObjectID: 1})
+	if !errors.Is(err, jdwp.ErrorInvalidThread) {
+		t.Fatalf("got %v, want ErrorInvalidThread", err)
+	}
+	if errors.Is(err, jdwp.ErrorInvalidObject) {
+		t.Fatalf("%v matched ErrorInvalidObject", err)
+	}
+	var jdwpErr *jdwp.Error
+	if !errors.As(err, &jdwpErr) {
+		t.Fatalf("got %T, want *jdwp.Error", err)
+	}
+	if jdwpErr.Commandset != thread.NameCommand.Commandset || jdwpErr.Command != thread.NameCommand.Command {
+		t.Fatalf("error for command %v/%v, want %v/%v", jdwpErr.Commandset, jdwpErr.Command,
+			thread.NameCommand.Commandset, thread.NameCommand.Command)
+	}
+}
+
+func TestCommandContext(t *testing.T) {
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		srv.Handle(vm.VersionCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			return nil
+		})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := core.WithContext(ctx).VMCommands().Version()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,47 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+)
+
+// EventRequestCommands expose the EventRequest commands
+type EventRequestCommands interface {
+	// Set registers the request and returns its request ID
+	Set(*eventrequest.SetCommandData) (int32, error)
+	Clear(event.Kind, int32) error
+	ClearAllBreakpoints() error
+}
+
+type eventRequestCommands struct {
+	*debuggercore
+}
+
+func (e *eventRequestCommands) Set(setCommandData *eventrequest.SetCommandData) (int32, error) {
+	var setReply eventrequest.SetReply
+	err := e.processCommand(eventrequest.SetCommand, setCommandData, &setReply)
+	if err != nil {
+		return 0, err
+	}
+	return setReply.RequestID, nil
+}
+
+func (e *eventRequestCommands) Clear(eventKind event.Kind, requestID int32) error {
+	clearCommandData := &eventrequest.ClearCommandData{
+		EventKind: eventKind,
+		RequestID: requestID,
+	}
+	err := e.processCommand(eventrequest.ClearCommand, clearCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *eventRequestCommands) ClearAllBreakpoints() error {
+	err := e.processCommand(eventrequest.ClearAllBreakpointsCommand, nil, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package debuggercore
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/jquirke/jdwpgo/internal/queue"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+)
+
+// EventCommands expose the event subscription API. An event no
+// subscription wants is discarded and logged; if its composite
+// suspended the VM or a thread, nothing resumes it, so a subscriber
+// should be in place before requesting events that suspend
+type EventCommands interface {
+	// Subscribe registers for events of the given kinds, or for all
+	// events if no kinds are given
+	Subscribe(kinds ...event.Kind) EventSubscription
+}
+
+// EventSubscription delivers events until it is unsubscribed or the
+// session ends, at which point the events channel is closed. Events
+// are queued per subscription without limit, so a subscriber that falls
+// behind never holds up delivery to the others, but must keep reading
+// or unsubscribe to release the queue
+type EventSubscription interface {
+	Events() <-chan *Event
+	Unsubscribe()
+}
+
+// Event is a single decoded event, along with the suspend policy of
+// the composite that delivered it
+type Event struct {
+	SuspendPolicy event.SuspendPolicy
+	RequestID     int32
+	Kind          event.Kind
+	Event         event.Event
+	// Composite is every event of the composite, this one included.
+	// The VM suspends once for the whole composite, so whoever resumes
+	// it must account for all of them
+	Composite []*Event
+}
+
+func (e *Event) String() string {
+	return fmt.Sprintf("SuspendPolicy: %v Event: %s", e.SuspendPolicy, e.Event.String())
+}
+
+type eventDispatcher struct {
+	mutex         sync.Mutex
+	subscriptions map[*eventSubscription]struct{}
+	closed        bool
+	// invoking counts the toString() invocations ExceptionCommands.Decode
+	// has running on each thread
+	invoking map[common.ThreadID]int
+}
+
+type eventSubscription struct {
+	dispatcher *eventDispatcher
+	kinds      map[event.Kind]struct{}
+	queue      *queue.Queue
+	eventCh    chan *Event
+	done       chan struct{}
+	once       sync.Once
+}
+
+func newEventDispatcher() *eventDispatcher {
+	return &eventDispatcher{
+		subscriptions: make(map[*eventSubscription]struct{}),
+		invoking:      make(map[common.ThreadID]int),
+	}
+}
+
+func (d *debuggercore) Subscribe(kinds ...event.Kind) EventSubscription {
+	return d.events.subscribe(kinds)
+}
+
+func (e *eventDispatcher) subscribe(kinds []event.Kind) *eventSubscription {
+	subscription := &eventSubscription{
+		dispatcher: e,
+		queue:      queue.New(0, queue.Block),
+		eventCh:    make(chan *Event),
+		done:       make(chan struct{}),
+	}
+	if len(kinds) > 0 {
+		subscription.kinds = make(map[event.Kind]struct{})
+		for _, kind := range kinds {
+			subscription.kinds[kind] = struct{}{}
+		}
+	}
+
+	go subscription.forward()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.closed {
+		subscription.queue.Close(false)
+		return subscription
+	}
+	e.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// run decodes composite commands from the VM and fans the events out
+// to subscribers until the session's command channel is closed
+func (e *eventDispatcher) run(packets <-chan *jdwpsession.CommandPacket, idSizes basetypes.IDSizes, logger *slog.Logger,
+	resume func(event.SuspendPolicy, common.ThreadID) error) {
+	for packet := range packets {
+		if packet.Commandset != event.CompositeCommand.Commandset ||
+			packet.Command != event.CompositeCommand.Command {
+			logger.Warn("ignoring unexpected command from VM", "packet", packet)
+			continue
+		}
+		composite, err := event.DecodeComposite(idSizes, packet.Data)
+		if err != nil {
+			logger.Warn("dropping undecodable composite event", "error", err)
+			continue
+		}
+		events := make([]*Event, len(composite.Events))
+		for idx, decoded := range composite.Events {
+			events[idx] = &Event{
+				SuspendPolicy: composite.SuspendPolicy,
+				RequestID:     decoded.EventRequestID(),
+				Kind:          decoded.Kind(),
+				Event:         decoded,
+				Composite:     events,
+			}
+		}
+		if thread, ok := e.thrownDuringInvoke(events); ok {
+			// The invocation would never return were its thread left
+			// suspended, so the composite goes straight to resume; not
+			// from this goroutine, as the reply must not wait on events
+			logger.Debug("resuming exception thrown while decoding an exception", "event", events[0].String())
+			go func(suspendPolicy event.SuspendPolicy) {
+				if err := resume(suspendPolicy, thread); err != nil {
+					logger.Warn("resuming after exception in invocation", "thread", thread, "error", err)
+				}
+			}(composite.SuspendPolicy)
+			continue
+		}
+		for _, ev := range events {
+			if e.dispatch(ev) {
+				continue
+			}
+			if ev.SuspendPolicy == event.SuspendPolicyNone {
+				logger.Debug("no subscriber for event", "event", ev.String())
+			} else {
+				logger.Warn("no subscriber for event, the VM stays suspended", "event", ev.String())
+			}
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.closed = true
+	for subscription := range e.subscriptions {
+		// Events already queued are still delivered
+		subscription.queue.Close(false)
+		delete(e.subscriptions, subscription)
+	}
+}
+
+// beginInvoke and endInvoke bracket a toString() invocation by
+// ExceptionCommands.Decode on thread
+func (e *eventDispatcher) beginInvoke(thread common.ThreadID) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.invoking[thread]++
+}
+
+func (e *eventDispatcher) endInvoke(thread common.ThreadID) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.invoking[thread]--; e.invoking[thread] <= 0 {
+		delete(e.invoking, thread)
+	}
+}
+
+// thrownDuringInvoke reports whether a composite is only exceptions
+// that suspended a thread running a toString() invocation, and which
+// thread. Its thread cannot finish the invocation until the composite
+// is resumed, so cannot have left invoking
+func (e *eventDispatcher) thrownDuringInvoke(events []*Event) (common.ThreadID, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var thread common.ThreadID
+	if len(events) == 0 || len(e.invoking) == 0 || events[0].SuspendPolicy == event.SuspendPolicyNone {
+		return thread, false
+	}
+	for _, ev := range events {
+		thrown, ok := ev.Event.(*event.Exception)
+		if !ok || e.invoking[thrown.Thread] == 0 || (thread != common.ThreadID{} && thrown.Thread != thread) {
+			return thread, false
+		}
+		thread = thrown.Thread
+	}
+	return thread, true
+}
+
+// resumeSuspended resumes what a composite with the given suspend
+// policy suspended, thread being the thread its events happened in
+func (d *debuggercore) resumeSuspended(suspendPolicy event.SuspendPolicy, thread common.ThreadID) error {
+	switch suspendPolicy {
+	case event.SuspendPolicyAll:
+		return d.VMCommands().Resume()
+	case event.SuspendPolicyEventThread:
+		return d.ThreadCommands().Resume(thread)
+	default:
+		return nil
+	}
+}
+
+// dispatch queues ev for each subscription that wants it, and reports
+// whether there were any; it never blocks on a subscriber
+func (e *eventDispatcher) dispatch(ev *Event) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	matched := false
+	for subscription := range e.subscriptions {
+		if subscription.wants(ev.Kind) {
+			subscription.queue.Push(ev)
+			matched = true
+		}
+	}
+	return matched
+}
+
+func (s *eventSubscription) wants(kind event.Kind) bool {
+	if s.kinds == nil {
+		return true
+	}
+	_, ok := s.kinds[kind]
+	return ok
+}
+
+// forward feeds the events channel from the queue, closing it once the
+// queue is closed and drained, or on Unsubscribe
+func (s *eventSubscription) forward() {
+	defer close(s.eventCh)
+	for {
+		ev, ok := s.queue.Pop()
+		if !ok {
+			return
+		}
+		select {
+		case s.eventCh <- ev.(*Event):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *eventSubscription) Events() <-chan *Event {
+	return s.eventCh
+}
+
+// Unsubscribe discards undelivered events and closes the events channel
+func (s *eventSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.done)
+		s.queue.Close(true)
+		s.dispatcher.mutex.Lock()
+		defer s.dispatcher.mutex.Unlock()
+		delete(s.dispatcher.subscriptions, s)
+	})
+}
@@ -0,0 +1,175 @@
+package debuggercore_test
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+)
+
+func receiveEvent(t *testing.T, subscription debuggercore.EventSubscription) *debuggercore.Event {
+	t.Helper()
+	select {
+	case ev, ok := <-subscription.Events():
+		if !ok {
+			t.Fatal("events channel closed")
+		}
+		return ev
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for event")
+	}
+	return nil
+}
+
+func TestSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	core, srv := startCore(t, nil)
+	slow := core.EventCommands().Subscribe()
+	defer slow.Unsubscribe()
+	fast := core.EventCommands().Subscribe(event.KindThreadStart)
+	defer fast.Unsubscribe()
+
+	const numEvents = 500
+	go func() {
+		for i := 0; i < numEvents; i++ {
+			srv.SendEvents(event.SuspendPolicyNone, &event.ThreadStart{RequestID: int32(i), Thread: common.ThreadID{ObjectID: 1}})
+		}
+	}()
+	for i := 0; i < numEvents; i++ {
+		if ev := receiveEvent(t, fast); ev.RequestID != int32(i) {
+			t.Fatalf("fast subscriber: got request %v, want %v", ev.RequestID, i)
+		}
+	}
+	// The slow subscriber has queued everything, in order
+	for i := 0; i < numEvents; i++ {
+		if ev := receiveEvent(t, slow); ev.RequestID != int32(i) {
+			t.Fatalf("slow subscriber: got request %v, want %v", ev.RequestID, i)
+		}
+	}
+}
+
+func TestUnsubscribeClosesEvents(t *testing.T) {
+	core, srv := startCore(t, nil)
+	subscription := core.EventCommands().Subscribe()
+	srv.SendEvents(event.SuspendPolicyNone, &event.ThreadStart{RequestID: 1})
+	srv.SendEvents(event.SuspendPolicyNone, &event.ThreadStart{RequestID: 2})
+	receiveEvent(t, subscription)
+	subscription.Unsubscribe()
+	select {
+	case _, ok := <-subscription.Events():
+		if ok {
+			// At most the event already handed to the channel
+			if _, ok := <-subscription.Events(); ok {
+				t.Fatal("events still delivered after Unsubscribe")
+			}
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("events channel not closed by Unsubscribe")
+	}
+}
+
+func TestSubscribeByKind(t *testing.T) {
+	core, srv := startCore(t, func(srv *jdwptest.Server) {
+		srv.IDSizes.ObjectIDSize = 4
+	})
+	all := core.EventCommands().Subscribe()
+	defer all.Unsubscribe()
+	deaths := core.EventCommands().Subscribe(event.KindThreadDeath)
+	defer deaths.Unsubscribe()
+
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.ThreadStart{RequestID: 1, Thread: common.ThreadID{ObjectID: 0x10}},
+		&event.ThreadDeath{RequestID: 2, Thread: common.ThreadID{ObjectID: 0x20}})
+
+	// a composite is delivered as one Event per event it holds
+	for _, want := range []*debuggercore.Event{
+		{SuspendPolicy: event.SuspendPolicyEventThread, RequestID: 1, Kind: event.KindThreadStart,
+			Event: &event.ThreadStart{RequestID: 1, Thread: common.ThreadID{ObjectID: 0x10}}},
+		{SuspendPolicy: event.SuspendPolicyEventThread, RequestID: 2, Kind: event.KindThreadDeath,
+			Event: &event.ThreadDeath{RequestID: 2, Thread: common.ThreadID{ObjectID: 0x20}}},
+	} {
+		ev := receiveEvent(t, all)
+		if len(ev.Composite) != 2 || ev.Composite[ev.RequestID-1] != ev {
+			t.Fatalf("all: %v not grouped with its composite: %v", ev, ev.Composite)
+		}
+		got := *ev
+		got.Composite = nil
+		if !reflect.DeepEqual(&got, want) {
+			t.Fatalf("all: got %v, want %v", &got, want)
+		}
+	}
+	if ev := receiveEvent(t, deaths); ev.Kind != event.KindThreadDeath {
+		t.Fatalf("deaths: got %v", ev)
+	}
+	select {
+	case ev := <-deaths.Events():
+		t.Fatalf("deaths: unexpected %v", ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSubscriptionClosedWhenSessionEnds(t *testing.T) {
+	core, srv := startCore(t, nil)
+	subscription := core.EventCommands().Subscribe()
+	srv.SendEvents(event.SuspendPolicyNone, &event.VMDeath{RequestID: 0})
+	srv.Close()
+	// events already received are still delivered
+	if ev := receiveEvent(t, subscription); ev.Kind != event.KindVMDeath {
+		t.Fatalf("got %v, want VMDeath", ev)
+	}
+	select {
+	case _, ok := <-subscription.Events():
+		if ok {
+			t.Fatal("got an event after VMDeath")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("events channel not closed when the session ended")
+	}
+
+	// subscribing afterwards gives a closed channel
+	late := core.EventCommands().Subscribe()
+	select {
+	case _, ok := <-late.Events():
+		if ok {
+			t.Fatal("late subscription got an event")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("late subscription not closed")
+	}
+}
+
+func TestUnwantedSuspendingEventLogged(t *testing.T) {
+	var logged bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logged, nil))
+	srv, conn := jdwptest.NewPipe()
+	srv.Start()
+	defer srv.Close()
+	session := jdwpsession.New(conn, jdwpsession.WithLogger(logger))
+	if err := session.Start(); err != nil {
+		t.Fatalf("session Start: %v", err)
+	}
+	defer session.Stop()
+	core, err := debuggercore.NewFromJWDPSession(session)
+	if err != nil {
+		t.Fatalf("NewFromJWDPSession: %v", err)
+	}
+	deaths := core.EventCommands().Subscribe(event.KindThreadDeath)
+	defer deaths.Unsubscribe()
+
+	srv.SendEvents(event.SuspendPolicyAll, &event.ThreadStart{RequestID: 1})
+	// events are dispatched in order, so once this one arrives the
+	// other has been dealt with
+	srv.SendEvents(event.SuspendPolicyNone, &event.ThreadDeath{RequestID: 2})
+	receiveEvent(t, deaths)
+
+	if got := logged.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, "the VM stays suspended") {
+		t.Fatalf("no warning logged: %q", got)
+	}
+}
@@ -0,0 +1,292 @@
+package debuggercore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jquirke/jdwpgo/internal/queue"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+)
+
+// ExceptionCommands manage exception breakpoints, which stop when an
+// exception is thrown rather than at a location
+type ExceptionCommands interface {
+	// Catch sets an exception breakpoint; its events are delivered,
+	// decoded, on the breakpoint's Events channel
+	Catch(ExceptionSpec) (*ExceptionBreakpoint, error)
+	Clear(*ExceptionBreakpoint) error
+	// Decode resolves an exception event's type and, if the event
+	// suspended its thread, its toString() message. If resolving either
+	// fails, the partially decoded event is returned along with the
+	// error. An exception the invocation itself throws is resumed
+	// rather than delivered, as the invocation could not otherwise
+	// return
+	Decode(*Event) (*ExceptionEvent, error)
+}
+
+// ExceptionSpec describes which throws an exception breakpoint stops at
+type ExceptionSpec struct {
+	// ExceptionClass is the name of the exception class, such as
+	// "java.lang.NullPointerException"; subclasses also match. Empty
+	// matches every exception. If the class is not loaded yet, the
+	// breakpoint is set once it is prepared
+	ExceptionClass string
+	// Caught and Uncaught select throws that will and will not be
+	// caught in the VM; at least one must be set
+	Caught   bool
+	Uncaught bool
+	// ClassMatch and ClassExclude restrict the classes the exception is
+	// thrown from, by name patterns such as "com.ourco.*"
+	ClassMatch    []string
+	ClassExclude  []string
+	SuspendPolicy event.SuspendPolicy
+}
+
+// ExceptionEvent is a decoded exception event
+type ExceptionEvent struct {
+	RequestID int32
+	Thread    common.ThreadID
+	// Location is where the exception was thrown
+	Location common.Location
+	// CatchLocation is nil if the exception is not caught
+	CatchLocation *common.Location
+	Exception     basetypes.JWDPTaggedObjectID
+	// ExceptionType is the class name of the exception object
+	ExceptionType string
+	// Message is the exception's toString(). It is empty if the event
+	// did not suspend its thread, or if toString() itself threw
+	Message string
+}
+
+func (e *ExceptionEvent) String() string {
+	catch := "uncaught"
+	if e.CatchLocation != nil {
+		catch = fmt.Sprintf("caught at %s", e.CatchLocation.String())
+	}
+	description := e.ExceptionType
+	if e.Message != "" {
+		description = e.Message
+	}
+	return fmt.Sprintf("%s thrown at %s, %s", description, e.Location.String(), catch)
+}
+
+// ExceptionBreakpoint is an exception breakpoint set through
+// ExceptionCommands. It holds one event request per loaded copy of the
+// exception class, including copies loaded after it was set
+type ExceptionBreakpoint struct {
+	Spec ExceptionSpec
+
+	mutex      sync.Mutex
+	classes    map[basetypes.JWDPRefTypeID]struct{}
+	requestIDs []int32
+
+	events       chan *ExceptionEvent
+	subscription EventSubscription
+	// thrown queues the breakpoint's events until they are decoded
+	thrown *queue.Queue
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Events delivers the breakpoint's events until it is cleared or the
+// session ends, at which point the channel is closed. The VM stays
+// suspended, per the spec's suspend policy, until the caller resumes it.
+// Events the caller has not read yet are queued without limit rather
+// than dropped, as each may be holding the VM suspended; a caller that
+// stops reading should Clear the breakpoint to release them
+func (e *ExceptionBreakpoint) Events() <-chan *ExceptionEvent {
+	return e.events
+}
+
+// RequestIDs returns the IDs of the breakpoint's event requests
+func (e *ExceptionBreakpoint) RequestIDs() []int32 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]int32(nil), e.requestIDs...)
+}
+
+func (e *ExceptionBreakpoint) owns(requestID int32) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, id := range e.requestIDs {
+		if id == requestID {
+			return true
+		}
+	}
+	return false
+}
+
+type exceptionCommands struct {
+	*debuggercore
+}
+
+func (e *exceptionCommands) Catch(spec ExceptionSpec) (*ExceptionBreakpoint, error) {
+	if !spec.Caught && !spec.Uncaught {
+		return nil, errors.New("exception breakpoint matches neither caught nor uncaught exceptions")
+	}
+	breakpoint := &ExceptionBreakpoint{
+		Spec:    spec,
+		classes: make(map[basetypes.JWDPRefTypeID]struct{}),
+		events:  make(chan *ExceptionEvent),
+		thrown:  queue.New(0, queue.Block),
+		done:    make(chan struct{}),
+		// Subscribe before any request is set, so no event is missed
+		subscription: e.Subscribe(event.KindException),
+	}
+	var err error
+	if spec.ExceptionClass == "" {
+		// A null reference type matches every exception
+		err = e.catchException(breakpoint, basetypes.JWDPRefTypeID{})
+	} else {
+		err = e.breakpoints.catch(e.debuggercore, breakpoint)
+	}
+	if err != nil {
+		e.Clear(breakpoint)
+		return nil, err
+	}
+
+	go e.deliver(breakpoint)
+	go e.decode(breakpoint)
+	return breakpoint, nil
+}
+
+// catchException sets an exception breakpoint's event request for a
+// copy of its exception class, unless it has one already
+func (d *debuggercore) catchException(breakpoint *ExceptionBreakpoint, exceptionClass basetypes.JWDPRefTypeID) error {
+	breakpoint.mutex.Lock()
+	defer breakpoint.mutex.Unlock()
+	if _, ok := breakpoint.classes[exceptionClass]; ok {
+		return nil
+	}
+	spec := breakpoint.Spec
+	setCommandData := eventrequest.New(event.KindException, spec.SuspendPolicy).
+		ExceptionOnly(exceptionClass, spec.Caught, spec.Uncaught)
+	for _, pattern := range spec.ClassMatch {
+		setCommandData.ClassMatch(pattern)
+	}
+	for _, pattern := range spec.ClassExclude {
+		setCommandData.ClassExclude(pattern)
+	}
+	requestID, err := d.EventRequestCommands().Set(setCommandData)
+	if err != nil {
+		return err
+	}
+	breakpoint.classes[exceptionClass] = struct{}{}
+	breakpoint.requestIDs = append(breakpoint.requestIDs, requestID)
+	return nil
+}
+
+func (e *exceptionCommands) Clear(breakpoint *ExceptionBreakpoint) error {
+	var firstErr error
+	breakpoint.once.Do(func() {
+		close(breakpoint.done)
+		breakpoint.subscription.Unsubscribe()
+		breakpoint.thrown.Close(true)
+		// no more requests are set once the class prepare is released
+		if breakpoint.Spec.ExceptionClass != "" {
+			firstErr = e.breakpoints.uncatch(e.debuggercore, breakpoint)
+		}
+		for _, requestID := range breakpoint.RequestIDs() {
+			err := e.EventRequestCommands().Clear(event.KindException, requestID)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
+
+// deliver queues the breakpoint's events to be decoded. It sends no
+// commands and never blocks, as decoding an event may itself cause
+// events: the toString() invocation can throw
+func (e *exceptionCommands) deliver(breakpoint *ExceptionBreakpoint) {
+	for ev := range breakpoint.subscription.Events() {
+		if breakpoint.owns(ev.RequestID) {
+			breakpoint.thrown.Push(ev)
+		}
+	}
+	// the session has ended, so what is queued is flushed; if instead
+	// the breakpoint was cleared, the queue is already discarded
+	breakpoint.thrown.Close(false)
+}
+
+// decode decodes queued events onto the Events channel. It outlives the
+// call to Catch, so is not bound to its context
+func (e *exceptionCommands) decode(breakpoint *ExceptionBreakpoint) {
+	defer close(breakpoint.events)
+	exceptions := e.WithContext(context.WithoutCancel(e.ctx)).ExceptionCommands()
+	logger := e.jdwpsession.Logger()
+	for {
+		item, ok := breakpoint.thrown.Pop()
+		if !ok {
+			return
+		}
+		ev := item.(*Event)
+		decoded, err := exceptions.Decode(ev)
+		if err != nil {
+			logger.Warn("decoding exception event", "event", ev.String(), "error", err)
+			if decoded == nil {
+				continue
+			}
+		}
+		select {
+		case breakpoint.events <- decoded:
+		case <-breakpoint.done:
+			return
+		}
+	}
+}
+
+func (e *exceptionCommands) Decode(ev *Event) (*ExceptionEvent, error) {
+	thrown, ok := ev.Event.(*event.Exception)
+	if !ok {
+		return nil, fmt.Errorf("not an exception event: %v", ev.Kind)
+	}
+	decoded := &ExceptionEvent{
+		RequestID: thrown.RequestID,
+		Thread:    thrown.Thread,
+		Location:  thrown.Location,
+		Exception: thrown.Exception,
+	}
+	// An uncaught exception has a catch location of all zeroes
+	if thrown.CatchLocation.ClassID.RefTypeID != 0 {
+		catchLocation := thrown.CatchLocation
+		decoded.CatchLocation = &catchLocation
+	}
+
+	exceptionObject := thrown.Exception.ObjectID
+	refType, err := e.ObjectCommands().ReferenceType(exceptionObject)
+	if err != nil {
+		return decoded, err
+	}
+	signature, err := e.ReferenceTypeCommands().Signature(refType.TypeID)
+	if err != nil {
+		return decoded, err
+	}
+	decoded.ExceptionType = common.SignatureToClassName(signature.String())
+
+	// Methods can only be invoked in a thread suspended by an event
+	if ev.SuspendPolicy == event.SuspendPolicyNone {
+		return decoded, nil
+	}
+	e.events.beginInvoke(thrown.Thread)
+	result, err := e.InvokeCommands().InvokeInstance(thrown.Thread, exceptionObject,
+		"toString", "()Ljava/lang/String;", nil, common.InvokeSingleThreaded)
+	e.events.endInvoke(thrown.Thread)
+	if err != nil {
+		return decoded, fmt.Errorf("invoking toString: %w", err)
+	}
+	if result.Threw() || result.Value.IsNull() {
+		return decoded, nil
+	}
+	decoded.Message, err = e.StringReferenceCommands().Value(result.Value.ObjectID())
+	if err != nil {
+		return decoded, fmt.Errorf("reading toString result: %w", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,428 @@
+package debuggercore_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/object"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/stringref"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const (
+	// illegalState is java.lang.IllegalStateException, which is loaded
+	illegalState = 0x50
+	// ourException is com.ourco.OurException, which is not
+	ourException = 0x51
+	// unanswered is an exception object the VM never answers for
+	unanswered = 0x61
+	// message is the string toString() returns
+	message = 0x70
+)
+
+var thrower = common.ThreadID{ObjectID: 5}
+
+// startExceptionVM serves the commands exception breakpoints use.
+// Exceptions thrown are IllegalStateExceptions, whose toString() is
+// method 9
+func startExceptionVM(t *testing.T) (debuggercore.DebuggerCore, *jdwptest.Server, *fakeEventRequests) {
+	var requests *fakeEventRequests
+	core, srv := startCore(t, func(srv *jdwptest.Server) {
+		requests = handleEventRequests(srv)
+		srv.Handle(object.ReferenceTypeCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData object.ReferenceTypeCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil || commandData.Object.ObjectID == unanswered {
+				return nil
+			}
+			return srv.StructReply(&object.ReferenceTypeReply{
+				RefTypeTag: basetypes.JWDPTypeTagClass,
+				TypeID:     basetypes.JWDPRefTypeID{RefTypeID: illegalState},
+			})
+		})
+		srv.HandleStruct(reftype.SignatureCommand, &reftype.SignatureReply{
+			Signature: basetypes.NewJDWPString("Ljava/lang/IllegalStateException;"),
+		})
+		srv.Handle(vm.ClassesBySignatureCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData vm.ClassesBySignatureCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+			}
+			reply := &vm.ClassesBySignatureReply{}
+			if commandData.Signature.String() == "Ljava/lang/IllegalStateException;" {
+				reply.NumClasses = 1
+				reply.Classes = []vm.ClassBySignature{{
+					RefTypeTag:      basetypes.JWDPTypeTagClass,
+					ReferenceTypeID: basetypes.JWDPRefTypeID{RefTypeID: illegalState},
+					Status:          vm.AllClassClassStatusVerified | vm.AllClassClassStatusPrepared,
+				}}
+			}
+			return srv.StructReply(reply)
+		})
+		handleClasses(srv, map[uint64]*fakeClass{
+			illegalState: {
+				modifiers: accPublic,
+				methods:   []reftype.Method{fakeMethod(9, "toString", "()Ljava/lang/String;", accPublic)},
+			},
+		})
+		srv.HandleStruct(object.InvokeMethodCommand, &object.InvokeMethodReply{
+			ReturnValue: basetypes.ObjectValue(basetypes.JWDPTagString, basetypes.JWDPObjectID{ObjectID: message}),
+		})
+		srv.HandleStruct(stringref.ValueCommand, &stringref.ValueReply{
+			StringValue: basetypes.NewJDWPString("java.lang.IllegalStateException: boom"),
+		})
+		srv.HandleData(thread.ResumeCommand, nil)
+		srv.HandleData(vm.ResumeCommand, nil)
+	})
+	return core, srv, requests
+}
+
+func thrown(requestID int32, index uint64) *event.Exception {
+	return &event.Exception{
+		RequestID: requestID,
+		Thread:    thrower,
+		Location:  stepLocation(index),
+		Exception: basetypes.JWDPTaggedObjectID{Tag: basetypes.JWDPTagObject, ObjectID: basetypes.JWDPObjectID{ObjectID: 0x60}},
+	}
+}
+
+func receiveException(t *testing.T, breakpoint *debuggercore.ExceptionBreakpoint) *debuggercore.ExceptionEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-breakpoint.Events():
+		if !ok {
+			t.Fatal("exception events channel closed")
+		}
+		return ev
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for exception event")
+	}
+	return nil
+}
+
+func TestCatchSetsRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		spec debuggercore.ExceptionSpec
+		want []eventrequest.Modifier
+	}{
+		{
+			name: "any exception",
+			spec: debuggercore.ExceptionSpec{
+				Caught:        true,
+				ClassMatch:    []string{"com.ourco.*"},
+				ClassExclude:  []string{"com.ourco.gen.*", "com.ourco.test.*"},
+				SuspendPolicy: event.SuspendPolicyAll,
+			},
+			want: []eventrequest.Modifier{
+				&eventrequest.ExceptionOnlyModifier{Caught: true},
+				&eventrequest.ClassMatchModifier{ClassPattern: basetypes.NewJDWPString("com.ourco.*")},
+				&eventrequest.ClassExcludeModifier{ClassPattern: basetypes.NewJDWPString("com.ourco.gen.*")},
+				&eventrequest.ClassExcludeModifier{ClassPattern: basetypes.NewJDWPString("com.ourco.test.*")},
+			},
+		},
+		{
+			name: "loaded class",
+			spec: debuggercore.ExceptionSpec{
+				ExceptionClass: "java.lang.IllegalStateException",
+				Uncaught:       true,
+				SuspendPolicy:  event.SuspendPolicyEventThread,
+			},
+			want: []eventrequest.Modifier{
+				&eventrequest.ExceptionOnlyModifier{ExceptionOrNull: basetypes.JWDPRefTypeID{RefTypeID: illegalState}, Uncaught: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, _, requests := startExceptionVM(t)
+			breakpoint, err := core.ExceptionCommands().Catch(tt.spec)
+			if err != nil {
+				t.Fatalf("Catch: %v", err)
+			}
+			active := requests.active(event.KindException)
+			if !reflect.DeepEqual(active, breakpoint.RequestIDs()) || len(active) != 1 {
+				t.Fatalf("got exception requests %v, breakpoint has %v", active, breakpoint.RequestIDs())
+			}
+			request := requests.get(active[0])
+			if request.SuspendPolicy != tt.spec.SuspendPolicy || !reflect.DeepEqual(request.Modifiers, tt.want) {
+				t.Fatalf("exception request: got %v, want modifiers %v", request, tt.want)
+			}
+
+			if err := core.ExceptionCommands().Clear(breakpoint); err != nil {
+				t.Fatalf("Clear: %v", err)
+			}
+			if active := requests.active(event.KindException); len(active) != 0 {
+				t.Fatalf("exception requests %v not cleared", active)
+			}
+			if active := requests.active(event.KindClassPrepare); len(active) != 0 {
+				t.Fatalf("ClassPrepare requests %v not cleared", active)
+			}
+		})
+	}
+}
+
+func TestCatchNeedsCaughtOrUncaught(t *testing.T) {
+	core, _, requests := startExceptionVM(t)
+	if _, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{}); err == nil {
+		t.Fatal("Catch: expected error")
+	}
+	if active := requests.active(event.KindException); len(active) != 0 {
+		t.Fatalf("exception requests %v set", active)
+	}
+}
+
+func TestCatchDeferredUntilClassPrepared(t *testing.T) {
+	core, srv, requests := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		ExceptionClass: "com.ourco.OurException",
+		Caught:         true,
+		Uncaught:       true,
+		SuspendPolicy:  event.SuspendPolicyEventThread,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	if active := requests.active(event.KindException); len(active) != 0 {
+		t.Fatalf("exception requests %v set before the class is loaded", active)
+	}
+	prepares := requests.active(event.KindClassPrepare)
+	if len(prepares) != 1 {
+		t.Fatalf("got ClassPrepare requests %v, want 1", prepares)
+	}
+	prepare := requests.get(prepares[0])
+	if prepare.SuspendPolicy != event.SuspendPolicyEventThread ||
+		prepare.Modifiers[0].(*eventrequest.ClassMatchModifier).ClassPattern.String() != "com.ourco.OurException" {
+		t.Fatalf("ClassPrepare request: %v", prepare)
+	}
+
+	srv.SendEvents(event.SuspendPolicyEventThread, &event.ClassPrepare{
+		RequestID:  prepares[0],
+		Thread:     common.ThreadID{ObjectID: 7},
+		RefTypeTag: basetypes.JWDPTypeTagClass,
+		TypeID:     basetypes.JWDPRefTypeID{RefTypeID: ourException},
+		Signature:  basetypes.NewJDWPString("Lcom/ourco/OurException;"),
+		Status:     vm.AllClassClassStatusVerified | vm.AllClassClassStatusPrepared,
+	})
+	waitFor(t, "the loading thread to be resumed", func() bool { return len(received(srv, thread.ResumeCommand)) > 0 })
+	active := requests.active(event.KindException)
+	if len(active) != 1 || !reflect.DeepEqual(active, breakpoint.RequestIDs()) {
+		t.Fatalf("got exception requests %v, breakpoint has %v", active, breakpoint.RequestIDs())
+	}
+	want := &eventrequest.ExceptionOnlyModifier{ExceptionOrNull: basetypes.JWDPRefTypeID{RefTypeID: ourException}, Caught: true, Uncaught: true}
+	if request := requests.get(active[0]); !reflect.DeepEqual(request.Modifiers, []eventrequest.Modifier{want}) {
+		t.Fatalf("exception request: %v", request)
+	}
+
+	if err := core.ExceptionCommands().Clear(breakpoint); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if active := requests.active(event.KindException); len(active) != 0 {
+		t.Fatalf("exception requests %v not cleared", active)
+	}
+	if active := requests.active(event.KindClassPrepare); len(active) != 0 {
+		t.Fatalf("ClassPrepare requests %v not cleared", active)
+	}
+}
+
+func TestExceptionCatchLocation(t *testing.T) {
+	core, srv, _ := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		Caught:        true,
+		Uncaught:      true,
+		SuspendPolicy: event.SuspendPolicyNone,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	requestID := breakpoint.RequestIDs()[0]
+	caught := thrown(requestID, 1)
+	caught.CatchLocation = stepLocation(8)
+	srv.SendEvents(event.SuspendPolicyNone, caught)
+	srv.SendEvents(event.SuspendPolicyNone, thrown(requestID, 2))
+
+	if ev := receiveException(t, breakpoint); ev.CatchLocation == nil || *ev.CatchLocation != stepLocation(8) {
+		t.Fatalf("caught exception: got catch location %v, want %v", ev.CatchLocation, stepLocation(8))
+	}
+	if ev := receiveException(t, breakpoint); ev.CatchLocation != nil {
+		t.Fatalf("uncaught exception: got catch location %v", ev.CatchLocation)
+	}
+}
+
+func TestExceptionMessage(t *testing.T) {
+	core, srv, _ := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		Uncaught:      true,
+		SuspendPolicy: event.SuspendPolicyEventThread,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	srv.SendEvents(event.SuspendPolicyEventThread, thrown(breakpoint.RequestIDs()[0], 1))
+
+	ev := receiveException(t, breakpoint)
+	if ev.ExceptionType != "java.lang.IllegalStateException" || ev.Message != "java.lang.IllegalStateException: boom" {
+		t.Fatalf("got %q with message %q", ev.ExceptionType, ev.Message)
+	}
+	invokes := received(srv, object.InvokeMethodCommand)
+	if len(invokes) != 1 {
+		t.Fatalf("got %v invocations, want 1", len(invokes))
+	}
+	var invoke object.InvokeMethodCommandData
+	if err := srv.UnpackCommand(invokes[0], &invoke); err != nil {
+		t.Fatalf("UnpackCommand: %v", err)
+	}
+	if invoke.Object.ObjectID != 0x60 || invoke.Thread != thrower || invoke.MethodID.MethodID != 9 ||
+		invoke.Options != common.InvokeSingleThreaded {
+		t.Fatalf("toString invocation: %+v", invoke)
+	}
+}
+
+func TestExceptionThrownDuringDecode(t *testing.T) {
+	core, srv, _ := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		Caught:        true,
+		Uncaught:      true,
+		SuspendPolicy: event.SuspendPolicyEventThread,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	requestID := breakpoint.RequestIDs()[0]
+
+	// toString() throws, which the breakpoint also matches, and so
+	// cannot return until its thread is resumed
+	resumed := make(chan struct{})
+	var once sync.Once
+	srv.Handle(thread.ResumeCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		once.Do(func() { close(resumed) })
+		return &jdwpsession.ReplyPacket{}
+	})
+	srv.InjectFault(object.InvokeMethodCommand, jdwptest.Fault{Wait: resumed})
+	srv.Handle(object.InvokeMethodCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		nested := thrown(requestID, 99)
+		nested.CatchLocation = stepLocation(100)
+		srv.SendEvents(event.SuspendPolicyEventThread, nested)
+		return srv.StructReply(&object.InvokeMethodReply{
+			ReturnValue: basetypes.ObjectValue(basetypes.JWDPTagString, basetypes.JWDPObjectID{ObjectID: message}),
+		})
+	})
+	srv.SendEvents(event.SuspendPolicyEventThread, thrown(requestID, 1))
+
+	ev := receiveException(t, breakpoint)
+	if ev.Location.Index != 1 || ev.Message != "java.lang.IllegalStateException: boom" {
+		t.Fatalf("got %v with message %q", ev, ev.Message)
+	}
+	// the nested exception was resumed, once, and not delivered
+	if resumes := received(srv, thread.ResumeCommand); len(resumes) != 1 {
+		t.Fatalf("thread resumed %v times, want 1", len(resumes))
+	}
+	select {
+	case ev := <-breakpoint.Events():
+		t.Fatalf("got nested exception %v", ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestExceptionEventsQueuedForSlowReader(t *testing.T) {
+	core, srv, _ := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		Caught:        true,
+		Uncaught:      true,
+		SuspendPolicy: event.SuspendPolicyNone,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	requestID := breakpoint.RequestIDs()[0]
+	others := core.EventCommands().Subscribe(event.KindException)
+	defer others.Unsubscribe()
+
+	// nothing reads the breakpoint's events while they are thrown
+	const numEvents = 200
+	for i := 0; i < numEvents; i++ {
+		srv.SendEvents(event.SuspendPolicyNone, thrown(requestID, uint64(i)))
+		srv.SendEvents(event.SuspendPolicyNone, thrown(requestID+1, uint64(i)))
+	}
+	for i := 0; i < 2*numEvents; i++ {
+		receiveEvent(t, others)
+	}
+
+	// none were dropped, and only the breakpoint's own are delivered
+	for i := 0; i < numEvents; i++ {
+		ev := receiveException(t, breakpoint)
+		if ev.RequestID != requestID || ev.Location.Index != uint64(i) {
+			t.Fatalf("got %v from request %v, want index %v", ev, ev.RequestID, i)
+		}
+		if ev.ExceptionType != "java.lang.IllegalStateException" {
+			t.Fatalf("ExceptionType: got %q", ev.ExceptionType)
+		}
+	}
+
+	srv.SendEvents(event.SuspendPolicyNone, thrown(requestID, numEvents))
+	receiveEvent(t, others)
+	if err := core.ExceptionCommands().Clear(breakpoint); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	// Clear discards what is still queued
+	select {
+	case _, ok := <-breakpoint.Events():
+		if ok {
+			if _, ok := <-breakpoint.Events(); ok {
+				t.Fatal("events still delivered after Clear")
+			}
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("exception events channel not closed by Clear")
+	}
+}
+
+func TestExceptionEventsFlushedWhenSessionEnds(t *testing.T) {
+	core, srv, _ := startExceptionVM(t)
+	breakpoint, err := core.ExceptionCommands().Catch(debuggercore.ExceptionSpec{
+		Uncaught:      true,
+		SuspendPolicy: event.SuspendPolicyNone,
+	})
+	if err != nil {
+		t.Fatalf("Catch: %v", err)
+	}
+	requestID := breakpoint.RequestIDs()[0]
+	others := core.EventCommands().Subscribe(event.KindException)
+	defer others.Unsubscribe()
+	const numEvents = 3
+	for i := 0; i < numEvents; i++ {
+		srv.SendEvents(event.SuspendPolicyNone, thrown(requestID, uint64(i)))
+	}
+	// once another subscriber has them, so does the breakpoint
+	for i := 0; i < numEvents; i++ {
+		receiveEvent(t, others)
+	}
+	srv.Close()
+
+	// those not decoded before the session ended are delivered as far
+	// as they could be
+	for i := 0; i < numEvents; i++ {
+		if ev := receiveException(t, breakpoint); ev.Location.Index != uint64(i) {
+			t.Fatalf("got %v, want index %v", ev, i)
+		}
+	}
+	select {
+	case ev, ok := <-breakpoint.Events():
+		if ok {
+			t.Fatalf("got %v after the session ended", ev)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("exception events channel not closed when the session ended")
+	}
+}
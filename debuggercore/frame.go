@@ -0,0 +1,188 @@
+package debuggercore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/stackframe"
+)
+
+// FrameCommands expose the StackFrame commands. Frame IDs are only
+// valid while their thread stays suspended, so errors for a thread that
+// is not suspended or a stale frame say so, and still match
+// jdwp.ErrorThreadNotSuspended and jdwp.ErrorInvalidFrameID with
+// errors.Is
+type FrameCommands interface {
+	GetValues(threadID common.ThreadID, frameID basetypes.JWDPFrameID, slots ...stackframe.Slot) ([]basetypes.JWDPValue, error)
+	SetValues(threadID common.ThreadID, frameID basetypes.JWDPFrameID, values ...stackframe.SlotValue) error
+	// ThisObject returns a null object for static and native methods
+	ThisObject(common.ThreadID, basetypes.JWDPFrameID) (basetypes.JWDPTaggedObjectID, error)
+	// PopFrames pops the frame and every frame above it
+	PopFrames(common.ThreadID, basetypes.JWDPFrameID) error
+	// Locals returns the local variables in scope in the frame with
+	// their values. It needs the class to have been compiled with
+	// local variable information (javac -g)
+	Locals(common.ThreadID, basetypes.JWDPFrameID) ([]Local, error)
+	// Local returns the local variable in scope with the given name
+	Local(threadID common.ThreadID, frameID basetypes.JWDPFrameID, name string) (*Local, error)
+}
+
+// Local is a local variable and its value in a frame
+type Local struct {
+	Name      string
+	Signature string
+	Slot      int32
+	Value     basetypes.JWDPValue
+}
+
+func (l *Local) String() string {
+	return fmt.Sprintf("%s %s = %s", l.Signature, l.Name, l.Value.String())
+}
+
+type frameCommands struct {
+	*debuggercore
+}
+
+// frameError explains the errors that come from using a frame ID
+// outside the suspension it belongs to
+func frameError(err error, threadID common.ThreadID, frameID basetypes.JWDPFrameID) error {
+	switch {
+	case errors.Is(err, jdwp.ErrorThreadNotSuspended):
+		return fmt.Errorf("thread 0x%X is not suspended, frames are only available while it is: %w",
+			threadID.ObjectID, err)
+	case errors.Is(err, jdwp.ErrorInvalidFrameID):
+		return fmt.Errorf("frame 0x%X of thread 0x%X is no longer valid, frame IDs do not survive the thread resuming: %w",
+			frameID.FrameID, threadID.ObjectID, err)
+	default:
+		return err
+	}
+}
+
+func (f *frameCommands) GetValues(threadID common.ThreadID, frameID basetypes.JWDPFrameID, slots ...stackframe.Slot) ([]basetypes.JWDPValue, error) {
+	getValuesCommandData := &stackframe.GetValuesCommandData{
+		Thread:   threadID,
+		Frame:    frameID,
+		NumSlots: int32(len(slots)),
+		Slots:    slots,
+	}
+	var getValuesReply stackframe.GetValuesReply
+	err := f.processCommand(stackframe.GetValuesCommand, getValuesCommandData, &getValuesReply)
+	if err != nil {
+		return nil, frameError(err, threadID, frameID)
+	}
+	return getValuesReply.Values, nil
+}
+
+func (f *frameCommands) SetValues(threadID common.ThreadID, frameID basetypes.JWDPFrameID, values ...stackframe.SlotValue) error {
+	setValuesCommandData := &stackframe.SetValuesCommandData{
+		Thread:        threadID,
+		Frame:         frameID,
+		NumSlotValues: int32(len(values)),
+		SlotValues:    values,
+	}
+	err := f.processCommand(stackframe.SetValuesCommand, setValuesCommandData, nil)
+	if err != nil {
+		return frameError(err, threadID, frameID)
+	}
+	return nil
+}
+
+func (f *frameCommands) ThisObject(threadID common.ThreadID, frameID basetypes.JWDPFrameID) (basetypes.JWDPTaggedObjectID, error) {
+	thisObjectCommandData := &stackframe.ThisObjectCommandData{
+		Thread: threadID,
+		Frame:  frameID,
+	}
+	var thisObjectReply stackframe.ThisObjectReply
+	err := f.processCommand(stackframe.ThisObjectCommand, thisObjectCommandData, &thisObjectReply)
+	if err != nil {
+		return basetypes.JWDPTaggedObjectID{}, frameError(err, threadID, frameID)
+	}
+	return thisObjectReply.ObjectThis, nil
+}
+
+func (f *frameCommands) PopFrames(threadID common.ThreadID, frameID basetypes.JWDPFrameID) error {
+	popFramesCommandData := &stackframe.PopFramesCommandData{
+		Thread: threadID,
+		Frame:  frameID,
+	}
+	err := f.processCommand(stackframe.PopFramesCommand, popFramesCommandData, nil)
+	if err != nil {
+		return frameError(err, threadID, frameID)
+	}
+	return nil
+}
+
+func (f *frameCommands) Locals(threadID common.ThreadID, frameID basetypes.JWDPFrameID) ([]Local, error) {
+	location, err := f.frameLocation(threadID, frameID)
+	if err != nil {
+		return nil, err
+	}
+	variableTable, err := f.MethodCommands().VariableTable(location.ClassID, location.MethodID)
+	if errors.Is(err, jdwp.ErrorAbsentInformation) {
+		return nil, fmt.Errorf("no local variable information, the class was compiled without -g: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	variables := variableTable.VisibleAt(int64(location.Index))
+	if len(variables) == 0 {
+		return nil, nil
+	}
+	slots := make([]stackframe.Slot, len(variables))
+	for idx, variable := range variables {
+		tag, err := basetypes.TagForSignature(variable.Signature.String())
+		if err != nil {
+			return nil, err
+		}
+		slots[idx] = stackframe.Slot{Slot: variable.Slot, SigByte: tag}
+	}
+	values, err := f.GetValues(threadID, frameID, slots...)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(variables) {
+		return nil, fmt.Errorf("asked for %v values, got %v", len(variables), len(values))
+	}
+	locals := make([]Local, len(variables))
+	for idx, variable := range variables {
+		locals[idx] = Local{
+			Name:      variable.Name.String(),
+			Signature: variable.Signature.String(),
+			Slot:      variable.Slot,
+			Value:     values[idx],
+		}
+	}
+	return locals, nil
+}
+
+func (f *frameCommands) Local(threadID common.ThreadID, frameID basetypes.JWDPFrameID, name string) (*Local, error) {
+	locals, err := f.Locals(threadID, frameID)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range locals {
+		if locals[idx].Name == name {
+			return &locals[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("no local variable %s in scope", name)
+}
+
+// frameLocation finds the location of a frame from the thread's stack
+func (f *frameCommands) frameLocation(threadID common.ThreadID, frameID basetypes.JWDPFrameID) (*common.Location, error) {
+	frames, err := f.ThreadCommands().Frames(threadID, 0, -1)
+	if err != nil {
+		return nil, frameError(err, threadID, frameID)
+	}
+	for idx := range frames.Frames {
+		if frames.Frames[idx].FrameID == frameID {
+			return &frames.Frames[idx].Location, nil
+		}
+	}
+	return nil, fmt.Errorf("frame 0x%X is not on the stack of thread 0x%X, frame IDs do not survive the thread resuming: %w",
+		frameID.FrameID, threadID.ObjectID, jdwp.ErrorInvalidFrameID)
+}
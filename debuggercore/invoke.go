@@ -0,0 +1,219 @@
+package debuggercore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+)
+
+// accInterface is the ACC_INTERFACE class access flag
+const accInterface = 0x0200
+
+// ErrMethodNotFound is returned when no method matches a name and
+// signature
+var ErrMethodNotFound = errors.New("method not found")
+
+// InvokeCommands invoke methods in the VM by name and JNI signature,
+// resolving the method against the class hierarchy first. An empty
+// signature matches any method of that name, provided it is not
+// overloaded. As with the underlying commands, thread must have been
+// suspended by an event
+type InvokeCommands interface {
+	// FindMethod looks for a method declared by refType, then its
+	// superclasses, then its superinterfaces
+	FindMethod(refType basetypes.JWDPRefTypeID, name string, signature string) (*reftype.Method, error)
+	// InvokeStatic runs a static method of a class, declared by it or
+	// one of its superclasses, or of an interface, declared by it
+	InvokeStatic(thread common.ThreadID, clazz basetypes.JWDPRefTypeID, name string, signature string,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error)
+	// InvokeInstance runs a method on obj, found by FindMethod from its
+	// runtime type. Dispatch is virtual unless options has
+	// InvokeNonvirtual, in which case the method found is run even if a
+	// subclass overrides it
+	InvokeInstance(thread common.ThreadID, obj basetypes.JWDPObjectID, name string, signature string,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error)
+	// NewInstance runs the constructor of clazz with the given
+	// signature, such as "(Ljava/lang/String;)V"
+	NewInstance(thread common.ThreadID, clazz basetypes.JWDPRefTypeID, signature string,
+		arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error)
+}
+
+// InvokeResult is the outcome of a method invoked in the VM
+type InvokeResult struct {
+	// Value is the return value, or the new object for NewInstance
+	Value basetypes.JWDPValue
+	// Exception is null unless the method threw, in which case Value
+	// is meaningless
+	Exception basetypes.JWDPTaggedObjectID
+}
+
+// Threw reports whether the method threw an exception
+func (i *InvokeResult) Threw() bool {
+	return i.Exception.ObjectID.ObjectID != 0
+}
+
+func (i *InvokeResult) String() string {
+	if i.Threw() {
+		return fmt.Sprintf("threw %s", i.Exception.String())
+	}
+	return i.Value.String()
+}
+
+type invokeCommands struct {
+	*debuggercore
+}
+
+func (i *invokeCommands) FindMethod(refType basetypes.JWDPRefTypeID, name string, signature string) (*reftype.Method, error) {
+	method, _, err := i.findMethod(refType, name, signature, true)
+	return method, err
+}
+
+// findMethod also returns the type that declares the method. Only
+// superclasses are searched unless withInterfaces is set; an interface
+// then has only itself searched
+func (i *invokeCommands) findMethod(refType basetypes.JWDPRefTypeID, name string, signature string,
+	withInterfaces bool) (*reftype.Method, basetypes.JWDPRefTypeID, error) {
+	refTypeCommands := i.ReferenceTypeCommands()
+	visited := make(map[basetypes.JWDPRefTypeID]bool)
+	var interfaces []basetypes.JWDPRefTypeID
+
+	search := func(refType basetypes.JWDPRefTypeID) (*reftype.Method, error) {
+		visited[refType] = true
+		methods, err := refTypeCommands.Methods(refType)
+		if err != nil {
+			return nil, err
+		}
+		method, err := matchMethod(methods, name, signature)
+		if err != nil || method != nil || !withInterfaces {
+			return method, err
+		}
+		superinterfaces, err := refTypeCommands.Interfaces(refType)
+		if err != nil {
+			return nil, err
+		}
+		interfaces = append(interfaces, superinterfaces...)
+		return nil, nil
+	}
+
+	// superclasses take precedence over default methods
+	modifiers, err := refTypeCommands.Modifiers(refType)
+	if err != nil {
+		return nil, basetypes.JWDPRefTypeID{}, err
+	}
+	for class := refType; class.RefTypeID != 0; {
+		method, err := search(class)
+		if err != nil || method != nil {
+			return method, class, err
+		}
+		if modifiers&accInterface != 0 {
+			break
+		}
+		class, err = i.ClassTypeCommands().Superclass(class)
+		if err != nil {
+			return nil, basetypes.JWDPRefTypeID{}, err
+		}
+	}
+	for len(interfaces) > 0 {
+		iface := interfaces[0]
+		interfaces = interfaces[1:]
+		if visited[iface] {
+			continue
+		}
+		method, err := search(iface)
+		if err != nil || method != nil {
+			return method, iface, err
+		}
+	}
+	return nil, basetypes.JWDPRefTypeID{}, fmt.Errorf("%w: %s%s", ErrMethodNotFound, name, signature)
+}
+
+// matchMethod returns nil without error if no declared method matches
+func matchMethod(methods *reftype.MethodsReply, name string, signature string) (*reftype.Method, error) {
+	var match *reftype.Method
+	for idx := range methods.Declared {
+		method := &methods.Declared[idx]
+		if method.Name.String() != name {
+			continue
+		}
+		if signature != "" && method.Signature.String() != signature {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("%s is overloaded, a signature is needed", name)
+		}
+		match = method
+	}
+	return match, nil
+}
+
+func (i *invokeCommands) InvokeStatic(thread common.ThreadID, clazz basetypes.JWDPRefTypeID, name string, signature string,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error) {
+	// static methods of interfaces are not inherited, so superinterfaces
+	// are not searched
+	method, _, err := i.findMethod(clazz, name, signature, false)
+	if err != nil {
+		return nil, err
+	}
+	modifiers, err := i.ReferenceTypeCommands().Modifiers(clazz)
+	if err != nil {
+		return nil, err
+	}
+	if modifiers&accInterface != 0 {
+		reply, err := i.InterfaceTypeCommands().InvokeMethod(clazz, thread, method.MethodID, arguments, options)
+		if err != nil {
+			return nil, err
+		}
+		return &InvokeResult{Value: reply.ReturnValue, Exception: reply.Exception}, nil
+	}
+	reply, err := i.ClassTypeCommands().InvokeMethod(clazz, thread, method.MethodID, arguments, options)
+	if err != nil {
+		return nil, err
+	}
+	return &InvokeResult{Value: reply.ReturnValue, Exception: reply.Exception}, nil
+}
+
+func (i *invokeCommands) InvokeInstance(thread common.ThreadID, obj basetypes.JWDPObjectID, name string, signature string,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error) {
+	refType, err := i.ObjectCommands().ReferenceType(obj)
+	if err != nil {
+		return nil, err
+	}
+	method, declaringType, err := i.findMethod(refType.TypeID, name, signature, true)
+	if err != nil {
+		return nil, err
+	}
+	// clazz is the declaring type, which is what a nonvirtual invoke
+	// runs; a virtual invoke still dispatches on the runtime type
+	reply, err := i.ObjectCommands().InvokeMethod(obj, thread, declaringType, method.MethodID, arguments, options)
+	if err != nil {
+		return nil, err
+	}
+	return &InvokeResult{Value: reply.ReturnValue, Exception: reply.Exception}, nil
+}
+
+func (i *invokeCommands) NewInstance(thread common.ThreadID, clazz basetypes.JWDPRefTypeID, signature string,
+	arguments []basetypes.JWDPValue, options common.InvokeOptions) (*InvokeResult, error) {
+	// constructors are not inherited, so only clazz is searched
+	methods, err := i.ReferenceTypeCommands().Methods(clazz)
+	if err != nil {
+		return nil, err
+	}
+	method, err := matchMethod(methods, "<init>", signature)
+	if err != nil {
+		return nil, err
+	}
+	if method == nil {
+		return nil, fmt.Errorf("%w: <init>%s", ErrMethodNotFound, signature)
+	}
+	reply, err := i.ClassTypeCommands().NewInstance(clazz, thread, method.MethodID, arguments, options)
+	if err != nil {
+		return nil, err
+	}
+	return &InvokeResult{
+		Value:     basetypes.ObjectValue(reply.NewObject.Tag, reply.NewObject.ObjectID),
+		Exception: reply.Exception,
+	}, nil
+}
@@ -0,0 +1,180 @@
+package debuggercore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/classtype"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/interfacetype"
+	"github.com/jquirke/jdwpgo/protocol/object"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+)
+
+const (
+	accPublic    = 0x0001
+	accStatic    = 0x0008
+	accInterface = 0x0200
+	accAbstract  = 0x0400
+)
+
+const (
+	baseClass = 1
+	subClass  = 2
+	iface     = 3
+	instance  = 0x100
+)
+
+// invokeHierarchy is Sub extends Base implements Iface:
+//
+//	Base:  overridden()V, inherited()V, staticBase()V
+//	Sub:   overridden()V
+//	Iface: defaulted()V, staticIface()V
+func invokeHierarchy() map[uint64]*fakeClass {
+	return map[uint64]*fakeClass{
+		baseClass: {
+			interfaces: []uint64{iface},
+			modifiers:  accPublic,
+			methods: []reftype.Method{
+				fakeMethod(11, "overridden", "()V", accPublic),
+				fakeMethod(12, "inherited", "()V", accPublic),
+				fakeMethod(13, "staticBase", "()V", accPublic|accStatic),
+			},
+		},
+		subClass: {
+			superclass: baseClass,
+			modifiers:  accPublic,
+			methods: []reftype.Method{
+				fakeMethod(21, "overridden", "()V", accPublic),
+			},
+		},
+		iface: {
+			modifiers: accPublic | accInterface | accAbstract,
+			methods: []reftype.Method{
+				fakeMethod(31, "defaulted", "()V", accPublic),
+				fakeMethod(32, "staticIface", "()V", accPublic|accStatic),
+			},
+		},
+	}
+}
+
+func TestInvokeInstancePassesDeclaringType(t *testing.T) {
+	var invoked []object.InvokeMethodCommandData
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		handleClasses(srv, invokeHierarchy())
+		srv.HandleStruct(object.ReferenceTypeCommand, &object.ReferenceTypeReply{
+			RefTypeTag: basetypes.JWDPTypeTagClass,
+			TypeID:     basetypes.JWDPRefTypeID{RefTypeID: subClass},
+		})
+		srv.Handle(object.InvokeMethodCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData object.InvokeMethodCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				t.Errorf("unpacking InvokeMethod: %v", err)
+			}
+			invoked = append(invoked, commandData)
+			return srv.StructReply(&object.InvokeMethodReply{ReturnValue: basetypes.VoidValue()})
+		})
+	})
+
+	tests := []struct {
+		name      string
+		wantClazz uint64
+		wantID    uint64
+	}{
+		{"overridden", subClass, 21},
+		{"inherited", baseClass, 12},
+		{"defaulted", iface, 31},
+	}
+	for _, tt := range tests {
+		invoked = nil
+		_, err := core.InvokeCommands().InvokeInstance(common.ThreadID{ObjectID: 1}, basetypes.JWDPObjectID{ObjectID: instance},
+			tt.name, "()V", nil, common.InvokeNonvirtual)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(invoked) != 1 {
+			t.Fatalf("%s: got %v invocations", tt.name, len(invoked))
+		}
+		if invoked[0].Clazz.RefTypeID != tt.wantClazz || invoked[0].MethodID.MethodID != tt.wantID {
+			t.Errorf("%s: invoked method %v in %v, want %v in %v", tt.name,
+				invoked[0].MethodID.MethodID, invoked[0].Clazz.RefTypeID, tt.wantID, tt.wantClazz)
+		}
+		if invoked[0].Options != common.InvokeNonvirtual {
+			t.Errorf("%s: options %v", tt.name, invoked[0].Options)
+		}
+	}
+}
+
+func TestInvokeStaticSearchesSuperclassesOnly(t *testing.T) {
+	var classInvoked []classtype.InvokeMethodCommandData
+	var interfaceInvoked []interfacetype.InvokeMethodCommandData
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		handleClasses(srv, invokeHierarchy())
+		srv.Handle(classtype.InvokeMethodCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData classtype.InvokeMethodCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				t.Errorf("unpacking InvokeMethod: %v", err)
+			}
+			classInvoked = append(classInvoked, commandData)
+			return srv.StructReply(&classtype.InvokeMethodReply{ReturnValue: basetypes.VoidValue()})
+		})
+		srv.Handle(interfacetype.InvokeMethodCommand, func(commandPacket *jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			var commandData interfacetype.InvokeMethodCommandData
+			if err := srv.UnpackCommand(commandPacket, &commandData); err != nil {
+				t.Errorf("unpacking InvokeMethod: %v", err)
+			}
+			interfaceInvoked = append(interfaceInvoked, commandData)
+			return srv.StructReply(&interfacetype.InvokeMethodReply{ReturnValue: basetypes.VoidValue()})
+		})
+	})
+	invokeCommands := core.InvokeCommands()
+	thread := common.ThreadID{ObjectID: 1}
+
+	// declared by a superclass
+	if _, err := invokeCommands.InvokeStatic(thread, basetypes.JWDPRefTypeID{RefTypeID: subClass}, "staticBase", "", nil, 0); err != nil {
+		t.Fatalf("staticBase: %v", err)
+	}
+	if len(classInvoked) != 1 || classInvoked[0].Clazz.RefTypeID != subClass || classInvoked[0].MethodID.MethodID != 13 {
+		t.Fatalf("staticBase: got %+v", classInvoked)
+	}
+
+	// static methods of interfaces are not inherited
+	_, err := invokeCommands.InvokeStatic(thread, basetypes.JWDPRefTypeID{RefTypeID: subClass}, "staticIface", "", nil, 0)
+	if !errors.Is(err, debuggercore.ErrMethodNotFound) {
+		t.Fatalf("staticIface through Sub: got %v, want ErrMethodNotFound", err)
+	}
+
+	// but can be invoked on the interface itself
+	if _, err := invokeCommands.InvokeStatic(thread, basetypes.JWDPRefTypeID{RefTypeID: iface}, "staticIface", "", nil, 0); err != nil {
+		t.Fatalf("staticIface: %v", err)
+	}
+	if len(interfaceInvoked) != 1 || interfaceInvoked[0].Clazz.RefTypeID != iface || interfaceInvoked[0].MethodID.MethodID != 32 {
+		t.Fatalf("staticIface: got %+v", interfaceInvoked)
+	}
+	if len(classInvoked) != 1 {
+		t.Fatalf("unexpected class invocations: %+v", classInvoked)
+	}
+}
+
+func TestFindMethod(t *testing.T) {
+	core, _ := startCore(t, func(srv *jdwptest.Server) {
+		handleClasses(srv, invokeHierarchy())
+	})
+	for name, wantID := range map[string]uint64{"overridden": 21, "inherited": 12, "defaulted": 31} {
+		method, err := core.InvokeCommands().FindMethod(basetypes.JWDPRefTypeID{RefTypeID: subClass}, name, "")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if method.MethodID.MethodID != wantID {
+			t.Errorf("%s: got method %v, want %v", name, method.MethodID.MethodID, wantID)
+		}
+	}
+	_, err := core.InvokeCommands().FindMethod(basetypes.JWDPRefTypeID{RefTypeID: subClass}, "missing", "")
+	if !errors.Is(err, debuggercore.ErrMethodNotFound) {
+		t.Fatalf("missing: got %v, want ErrMethodNotFound", err)
+	}
+}
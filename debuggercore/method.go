@@ -0,0 +1,87 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/method"
+)
+
+// MethodCommands expose the Method commands. Line and variable tables
+// fail with jdwp.ErrorAbsentInformation if the class was compiled
+// without debug information, and jdwp.ErrorNativeMethod for native
+// methods
+type MethodCommands interface {
+	LineTable(basetypes.JWDPRefTypeID, basetypes.JWDPMethodID) (*method.LineTableReply, error)
+	VariableTable(basetypes.JWDPRefTypeID, basetypes.JWDPMethodID) (*method.VariableTableReply, error)
+	VariableTableWithGeneric(basetypes.JWDPRefTypeID, basetypes.JWDPMethodID) (*method.VariableTableWithGenericReply, error)
+	Bytecodes(basetypes.JWDPRefTypeID, basetypes.JWDPMethodID) ([]byte, error)
+	IsObsolete(basetypes.JWDPRefTypeID, basetypes.JWDPMethodID) (bool, error)
+}
+
+type methodCommands struct {
+	*debuggercore
+}
+
+func (m *methodCommands) LineTable(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) (*method.LineTableReply, error) {
+	lineTableCommandData := &method.LineTableCommandData{
+		RefType:  refType,
+		MethodID: methodID,
+	}
+	var lineTableReply method.LineTableReply
+	err := m.processCommand(method.LineTableCommand, lineTableCommandData, &lineTableReply)
+	if err != nil {
+		return nil, err
+	}
+	return &lineTableReply, nil
+}
+
+func (m *methodCommands) VariableTable(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) (*method.VariableTableReply, error) {
+	variableTableCommandData := &method.VariableTableCommandData{
+		RefType:  refType,
+		MethodID: methodID,
+	}
+	var variableTableReply method.VariableTableReply
+	err := m.processCommand(method.VariableTableCommand, variableTableCommandData, &variableTableReply)
+	if err != nil {
+		return nil, err
+	}
+	return &variableTableReply, nil
+}
+
+func (m *methodCommands) VariableTableWithGeneric(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) (*method.VariableTableWithGenericReply, error) {
+	variableTableWithGenericCommandData := &method.VariableTableWithGenericCommandData{
+		RefType:  refType,
+		MethodID: methodID,
+	}
+	var variableTableWithGenericReply method.VariableTableWithGenericReply
+	err := m.processCommand(method.VariableTableWithGenericCommand, variableTableWithGenericCommandData, &variableTableWithGenericReply)
+	if err != nil {
+		return nil, err
+	}
+	return &variableTableWithGenericReply, nil
+}
+
+func (m *methodCommands) Bytecodes(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) ([]byte, error) {
+	bytecodesCommandData := &method.BytecodesCommandData{
+		RefType:  refType,
+		MethodID: methodID,
+	}
+	var bytecodesReply method.BytecodesReply
+	err := m.processCommand(method.BytecodesCommand, bytecodesCommandData, &bytecodesReply)
+	if err != nil {
+		return nil, err
+	}
+	return bytecodesReply.Bytes, nil
+}
+
+func (m *methodCommands) IsObsolete(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) (bool, error) {
+	isObsoleteCommandData := &method.IsObsoleteCommandData{
+		RefType:  refType,
+		MethodID: methodID,
+	}
+	var isObsoleteReply method.IsObsoleteReply
+	err := m.processCommand(method.IsObsoleteCommand, isObsoleteCommandData, &isObsoleteReply)
+	if err != nil {
+		return false, err
+	}
+	return isObsoleteReply.IsObsolete, nil
+}
@@ -0,0 +1,136 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/object"
+)
+
+// ObjectCommands expose the ObjectReference commands
+type ObjectCommands interface {
+	// Basics
+	ReferenceType(basetypes.JWDPObjectID) (*object.ReferenceTypeReply, error)
+	// GetValues returns the values of instance or static fields, in order
+	GetValues(obj basetypes.JWDPObjectID, fields ...basetypes.JWDPFieldID) ([]basetypes.JWDPValue, error)
+	SetValues(obj basetypes.JWDPObjectID, values ...object.FieldValue) error
+	MonitorInfo(basetypes.JWDPObjectID) (*object.MonitorInfoReply, error)
+	// InvokeMethod runs a method of clazz, or one of its supertypes, on
+	// obj in thread, which must be suspended by an event
+	InvokeMethod(obj basetypes.JWDPObjectID, thread common.ThreadID, clazz basetypes.JWDPRefTypeID,
+		methodID basetypes.JWDPMethodID, arguments []basetypes.JWDPValue, options common.InvokeOptions) (*object.InvokeMethodReply, error)
+	// Garbage collection
+	DisableCollection(basetypes.JWDPObjectID) error
+	EnableCollection(basetypes.JWDPObjectID) error
+	IsCollected(basetypes.JWDPObjectID) (bool, error)
+	// ReferringObjects; a maxReferrers of 0 returns all referrers
+	ReferringObjects(obj basetypes.JWDPObjectID, maxReferrers int32) ([]basetypes.JWDPTaggedObjectID, error)
+}
+
+type objectCommands struct {
+	*debuggercore
+}
+
+func (o *objectCommands) ReferenceType(obj basetypes.JWDPObjectID) (*object.ReferenceTypeReply, error) {
+	referenceTypeCommandData := &object.ReferenceTypeCommandData{
+		Object: obj,
+	}
+	var referenceTypeReply object.ReferenceTypeReply
+	err := o.processCommand(object.ReferenceTypeCommand, referenceTypeCommandData, &referenceTypeReply)
+	if err != nil {
+		return nil, err
+	}
+	return &referenceTypeReply, nil
+}
+
+func (o *objectCommands) GetValues(obj basetypes.JWDPObjectID, fields ...basetypes.JWDPFieldID) ([]basetypes.JWDPValue, error) {
+	getValuesCommandData := &object.GetValuesCommandData{
+		Object:    obj,
+		NumFields: int32(len(fields)),
+		Fields:    fields,
+	}
+	var getValuesReply object.GetValuesReply
+	err := o.processCommand(object.GetValuesCommand, getValuesCommandData, &getValuesReply)
+	if err != nil {
+		return nil, err
+	}
+	return getValuesReply.Values, nil
+}
+
+func (o *objectCommands) SetValues(obj basetypes.JWDPObjectID, values ...object.FieldValue) error {
+	setValuesCommandData := &object.SetValuesCommandData{
+		Object:    obj,
+		NumValues: int32(len(values)),
+		Values:    values,
+	}
+	return o.processCommand(object.SetValuesCommand, setValuesCommandData, nil)
+}
+
+func (o *objectCommands) MonitorInfo(obj basetypes.JWDPObjectID) (*object.MonitorInfoReply, error) {
+	monitorInfoCommandData := &object.MonitorInfoCommandData{
+		Object: obj,
+	}
+	var monitorInfoReply object.MonitorInfoReply
+	err := o.processCommand(object.MonitorInfoCommand, monitorInfoCommandData, &monitorInfoReply)
+	if err != nil {
+		return nil, err
+	}
+	return &monitorInfoReply, nil
+}
+
+func (o *objectCommands) InvokeMethod(obj basetypes.JWDPObjectID, thread common.ThreadID, clazz basetypes.JWDPRefTypeID,
+	methodID basetypes.JWDPMethodID, arguments []basetypes.JWDPValue, options common.InvokeOptions) (*object.InvokeMethodReply, error) {
+	invokeMethodCommandData := &object.InvokeMethodCommandData{
+		Object:       obj,
+		Thread:       thread,
+		Clazz:        clazz,
+		MethodID:     methodID,
+		NumArguments: int32(len(arguments)),
+		Arguments:    arguments,
+		Options:      options,
+	}
+	var invokeMethodReply object.InvokeMethodReply
+	err := o.processCommand(object.InvokeMethodCommand, invokeMethodCommandData, &invokeMethodReply)
+	if err != nil {
+		return nil, err
+	}
+	return &invokeMethodReply, nil
+}
+
+func (o *objectCommands) DisableCollection(obj basetypes.JWDPObjectID) error {
+	disableCollectionCommandData := &object.DisableCollectionCommandData{
+		Object: obj,
+	}
+	return o.processCommand(object.DisableCollectionCommand, disableCollectionCommandData, nil)
+}
+
+func (o *objectCommands) EnableCollection(obj basetypes.JWDPObjectID) error {
+	enableCollectionCommandData := &object.EnableCollectionCommandData{
+		Object: obj,
+	}
+	return o.processCommand(object.EnableCollectionCommand, enableCollectionCommandData, nil)
+}
+
+func (o *objectCommands) IsCollected(obj basetypes.JWDPObjectID) (bool, error) {
+	isCollectedCommandData := &object.IsCollectedCommandData{
+		Object: obj,
+	}
+	var isCollectedReply object.IsCollectedReply
+	err := o.processCommand(object.IsCollectedCommand, isCollectedCommandData, &isCollectedReply)
+	if err != nil {
+		return false, err
+	}
+	return isCollectedReply.IsCollected, nil
+}
+
+func (o *objectCommands) ReferringObjects(obj basetypes.JWDPObjectID, maxReferrers int32) ([]basetypes.JWDPTaggedObjectID, error) {
+	referringObjectsCommandData := &object.ReferringObjectsCommandData{
+		Object:       obj,
+		MaxReferrers: maxReferrers,
+	}
+	var referringObjectsReply object.ReferringObjectsReply
+	err := o.processCommand(object.ReferringObjectsCommand, referringObjectsCommandData, &referringObjectsReply)
+	if err != nil {
+		return nil, err
+	}
+	return referringObjectsReply.ReferringObjects, nil
+}
@@ -0,0 +1,271 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+// ReferenceTypeCommands expose the ReferenceType commands
+type ReferenceTypeCommands interface {
+	// Basics
+	Signature(basetypes.JWDPRefTypeID) (basetypes.JDWPString, error)
+	SignatureWithGeneric(basetypes.JWDPRefTypeID) (*reftype.SignatureWithGenericReply, error)
+	ClassLoader(basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error)
+	Modifiers(basetypes.JWDPRefTypeID) (int32, error)
+	Status(basetypes.JWDPRefTypeID) (vm.AllClassClassStatus, error)
+	ClassObject(basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error)
+	Module(basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error)
+	// Members
+	Fields(basetypes.JWDPRefTypeID) (*reftype.FieldsReply, error)
+	FieldsWithGeneric(basetypes.JWDPRefTypeID) (*reftype.FieldsWithGenericReply, error)
+	Methods(basetypes.JWDPRefTypeID) (*reftype.MethodsReply, error)
+	MethodsWithGeneric(basetypes.JWDPRefTypeID) (*reftype.MethodsWithGenericReply, error)
+	// GetValues returns the values of static fields, in order
+	GetValues(refType basetypes.JWDPRefTypeID, fields ...basetypes.JWDPFieldID) ([]basetypes.JWDPValue, error)
+	// Hierarchy
+	NestedTypes(basetypes.JWDPRefTypeID) (*reftype.NestedTypesReply, error)
+	Interfaces(basetypes.JWDPRefTypeID) ([]basetypes.JWDPRefTypeID, error)
+	// Source and class file
+	SourceFile(basetypes.JWDPRefTypeID) (basetypes.JDWPString, error)
+	SourceDebugExtension(basetypes.JWDPRefTypeID) (basetypes.JDWPString, error)
+	ClassFileVersion(basetypes.JWDPRefTypeID) (*reftype.ClassFileVersionReply, error)
+	ConstantPool(basetypes.JWDPRefTypeID) (*reftype.ConstantPoolReply, error)
+	// Heap; a maxInstances of 0 returns all instances
+	Instances(refType basetypes.JWDPRefTypeID, maxInstances int32) ([]basetypes.JWDPTaggedObjectID, error)
+}
+
+type referenceTypeCommands struct {
+	*debuggercore
+}
+
+func (r *referenceTypeCommands) Signature(refType basetypes.JWDPRefTypeID) (basetypes.JDWPString, error) {
+	signatureCommandData := &reftype.SignatureCommandData{
+		RefType: refType,
+	}
+	var signatureReply reftype.SignatureReply
+	err := r.processCommand(reftype.SignatureCommand, signatureCommandData, &signatureReply)
+	if err != nil {
+		return basetypes.EmptyJWDPString(), err
+	}
+	return signatureReply.Signature, nil
+}
+
+func (r *referenceTypeCommands) SignatureWithGeneric(refType basetypes.JWDPRefTypeID) (*reftype.SignatureWithGenericReply, error) {
+	signatureWithGenericCommandData := &reftype.SignatureWithGenericCommandData{
+		RefType: refType,
+	}
+	var signatureWithGenericReply reftype.SignatureWithGenericReply
+	err := r.processCommand(reftype.SignatureWithGenericCommand, signatureWithGenericCommandData, &signatureWithGenericReply)
+	if err != nil {
+		return nil, err
+	}
+	return &signatureWithGenericReply, nil
+}
+
+func (r *referenceTypeCommands) ClassLoader(refType basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error) {
+	classLoaderCommandData := &reftype.ClassLoaderCommandData{
+		RefType: refType,
+	}
+	var classLoaderReply reftype.ClassLoaderReply
+	err := r.processCommand(reftype.ClassLoaderCommand, classLoaderCommandData, &classLoaderReply)
+	if err != nil {
+		return basetypes.JWDPObjectID{}, err
+	}
+	return classLoaderReply.ClassLoader, nil
+}
+
+func (r *referenceTypeCommands) Modifiers(refType basetypes.JWDPRefTypeID) (int32, error) {
+	modifiersCommandData := &reftype.ModifiersCommandData{
+		RefType: refType,
+	}
+	var modifiersReply reftype.ModifiersReply
+	err := r.processCommand(reftype.ModifiersCommand, modifiersCommandData, &modifiersReply)
+	if err != nil {
+		return 0, err
+	}
+	return modifiersReply.ModBits, nil
+}
+
+func (r *referenceTypeCommands) Status(refType basetypes.JWDPRefTypeID) (vm.AllClassClassStatus, error) {
+	statusCommandData := &reftype.StatusCommandData{
+		RefType: refType,
+	}
+	var statusReply reftype.StatusReply
+	err := r.processCommand(reftype.StatusCommand, statusCommandData, &statusReply)
+	if err != nil {
+		return 0, err
+	}
+	return statusReply.Status, nil
+}
+
+func (r *referenceTypeCommands) ClassObject(refType basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error) {
+	classObjectCommandData := &reftype.ClassObjectCommandData{
+		RefType: refType,
+	}
+	var classObjectReply reftype.ClassObjectReply
+	err := r.processCommand(reftype.ClassObjectCommand, classObjectCommandData, &classObjectReply)
+	if err != nil {
+		return basetypes.JWDPObjectID{}, err
+	}
+	return classObjectReply.ClassObject, nil
+}
+
+func (r *referenceTypeCommands) Module(refType basetypes.JWDPRefTypeID) (basetypes.JWDPObjectID, error) {
+	moduleCommandData := &reftype.ModuleCommandData{
+		RefType: refType,
+	}
+	var moduleReply reftype.ModuleReply
+	err := r.processCommand(reftype.ModuleCommand, moduleCommandData, &moduleReply)
+	if err != nil {
+		return basetypes.JWDPObjectID{}, err
+	}
+	return moduleReply.Module, nil
+}
+
+func (r *referenceTypeCommands) Fields(refType basetypes.JWDPRefTypeID) (*reftype.FieldsReply, error) {
+	fieldsCommandData := &reftype.FieldsCommandData{
+		RefType: refType,
+	}
+	var fieldsReply reftype.FieldsReply
+	err := r.processCommand(reftype.FieldsCommand, fieldsCommandData, &fieldsReply)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldsReply, nil
+}
+
+func (r *referenceTypeCommands) FieldsWithGeneric(refType basetypes.JWDPRefTypeID) (*reftype.FieldsWithGenericReply, error) {
+	fieldsWithGenericCommandData := &reftype.FieldsWithGenericCommandData{
+		RefType: refType,
+	}
+	var fieldsWithGenericReply reftype.FieldsWithGenericReply
+	err := r.processCommand(reftype.FieldsWithGenericCommand, fieldsWithGenericCommandData, &fieldsWithGenericReply)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldsWithGenericReply, nil
+}
+
+func (r *referenceTypeCommands) Methods(refType basetypes.JWDPRefTypeID) (*reftype.MethodsReply, error) {
+	methodsCommandData := &reftype.MethodsCommandData{
+		RefType: refType,
+	}
+	var methodsReply reftype.MethodsReply
+	err := r.processCommand(reftype.MethodsCommand, methodsCommandData, &methodsReply)
+	if err != nil {
+		return nil, err
+	}
+	return &methodsReply, nil
+}
+
+func (r *referenceTypeCommands) MethodsWithGeneric(refType basetypes.JWDPRefTypeID) (*reftype.MethodsWithGenericReply, error) {
+	methodsWithGenericCommandData := &reftype.MethodsWithGenericCommandData{
+		RefType: refType,
+	}
+	var methodsWithGenericReply reftype.MethodsWithGenericReply
+	err := r.processCommand(reftype.MethodsWithGenericCommand, methodsWithGenericCommandData, &methodsWithGenericReply)
+	if err != nil {
+		return nil, err
+	}
+	return &methodsWithGenericReply, nil
+}
+
+func (r *referenceTypeCommands) GetValues(refType basetypes.JWDPRefTypeID, fields ...basetypes.JWDPFieldID) ([]basetypes.JWDPValue, error) {
+	getValuesCommandData := &reftype.GetValuesCommandData{
+		RefType:   refType,
+		NumFields: int32(len(fields)),
+		Fields:    fields,
+	}
+	var getValuesReply reftype.GetValuesReply
+	err := r.processCommand(reftype.GetValuesCommand, getValuesCommandData, &getValuesReply)
+	if err != nil {
+		return nil, err
+	}
+	return getValuesReply.Values, nil
+}
+
+func (r *referenceTypeCommands) NestedTypes(refType basetypes.JWDPRefTypeID) (*reftype.NestedTypesReply, error) {
+	nestedTypesCommandData := &reftype.NestedTypesCommandData{
+		RefType: refType,
+	}
+	var nestedTypesReply reftype.NestedTypesReply
+	err := r.processCommand(reftype.NestedTypesCommand, nestedTypesCommandData, &nestedTypesReply)
+	if err != nil {
+		return nil, err
+	}
+	return &nestedTypesReply, nil
+}
+
+func (r *referenceTypeCommands) Interfaces(refType basetypes.JWDPRefTypeID) ([]basetypes.JWDPRefTypeID, error) {
+	interfacesCommandData := &reftype.InterfacesCommandData{
+		RefType: refType,
+	}
+	var interfacesReply reftype.InterfacesReply
+	err := r.processCommand(reftype.InterfacesCommand, interfacesCommandData, &interfacesReply)
+	if err != nil {
+		return nil, err
+	}
+	return interfacesReply.Interfaces, nil
+}
+
+func (r *referenceTypeCommands) SourceFile(refType basetypes.JWDPRefTypeID) (basetypes.JDWPString, error) {
+	sourceFileCommandData := &reftype.SourceFileCommandData{
+		RefType: refType,
+	}
+	var sourceFileReply reftype.SourceFileReply
+	err := r.processCommand(reftype.SourceFileCommand, sourceFileCommandData, &sourceFileReply)
+	if err != nil {
+		return basetypes.EmptyJWDPString(), err
+	}
+	return sourceFileReply.SourceFile, nil
+}
+
+func (r *referenceTypeCommands) SourceDebugExtension(refType basetypes.JWDPRefTypeID) (basetypes.JDWPString, error) {
+	sourceDebugExtensionCommandData := &reftype.SourceDebugExtensionCommandData{
+		RefType: refType,
+	}
+	var sourceDebugExtensionReply reftype.SourceDebugExtensionReply
+	err := r.processCommand(reftype.SourceDebugExtensionCommand, sourceDebugExtensionCommandData, &sourceDebugExtensionReply)
+	if err != nil {
+		return basetypes.EmptyJWDPString(), err
+	}
+	return sourceDebugExtensionReply.Extension, nil
+}
+
+func (r *referenceTypeCommands) ClassFileVersion(refType basetypes.JWDPRefTypeID) (*reftype.ClassFileVersionReply, error) {
+	classFileVersionCommandData := &reftype.ClassFileVersionCommandData{
+		RefType: refType,
+	}
+	var classFileVersionReply reftype.ClassFileVersionReply
+	err := r.processCommand(reftype.ClassFileVersionCommand, classFileVersionCommandData, &classFileVersionReply)
+	if err != nil {
+		return nil, err
+	}
+	return &classFileVersionReply, nil
+}
+
+func (r *referenceTypeCommands) ConstantPool(refType basetypes.JWDPRefTypeID) (*reftype.ConstantPoolReply, error) {
+	constantPoolCommandData := &reftype.ConstantPoolCommandData{
+		RefType: refType,
+	}
+	var constantPoolReply reftype.ConstantPoolReply
+	err := r.processCommand(reftype.ConstantPoolCommand, constantPoolCommandData, &constantPoolReply)
+	if err != nil {
+		return nil, err
+	}
+	return &constantPoolReply, nil
+}
+
+func (r *referenceTypeCommands) Instances(refType basetypes.JWDPRefTypeID, maxInstances int32) ([]basetypes.JWDPTaggedObjectID, error) {
+	instancesCommandData := &reftype.InstancesCommandData{
+		RefType:      refType,
+		MaxInstances: maxInstances,
+	}
+	var instancesReply reftype.InstancesReply
+	err := r.processCommand(reftype.InstancesCommand, instancesCommandData, &instancesReply)
+	if err != nil {
+		return nil, err
+	}
+	return instancesReply.Instances, nil
+}
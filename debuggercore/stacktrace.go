@@ -0,0 +1,138 @@
+package debuggercore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/method"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+)
+
+// StackFrame is a thread frame with its location resolved to names
+type StackFrame struct {
+	FrameID         basetypes.JWDPFrameID
+	Location        common.Location
+	ClassSignature  string
+	ClassName       string
+	MethodName      string
+	MethodSignature string
+	// SourceFile is empty if the class has no source information
+	SourceFile string
+	// LineNumber is -1 if the method has no line information
+	LineNumber int32
+}
+
+func (s *StackFrame) String() string {
+	source := "Unknown Source"
+	if s.SourceFile != "" {
+		source = s.SourceFile
+		if s.LineNumber >= 0 {
+			source = fmt.Sprintf("%s:%d", s.SourceFile, s.LineNumber)
+		}
+	}
+	return fmt.Sprintf("%s.%s(%s)", s.ClassName, s.MethodName, source)
+}
+
+// stackClassInfo caches what is needed to resolve frames in a class
+type stackClassInfo struct {
+	signature  string
+	sourceFile string
+	methods    *reftype.MethodsReply
+	lineTables map[basetypes.JWDPMethodID]*method.LineTableReply
+}
+
+func (t *threadCommands) StackTrace(threadID common.ThreadID) ([]StackFrame, error) {
+	framesReply, err := t.Frames(threadID, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	classes := make(map[basetypes.JWDPRefTypeID]*stackClassInfo)
+	stackFrames := make([]StackFrame, 0, len(framesReply.Frames))
+	for _, frame := range framesReply.Frames {
+		stackFrame, err := t.resolveFrame(classes, frame)
+		if err != nil {
+			return nil, err
+		}
+		stackFrames = append(stackFrames, *stackFrame)
+	}
+	return stackFrames, nil
+}
+
+func (t *threadCommands) resolveFrame(classes map[basetypes.JWDPRefTypeID]*stackClassInfo, frame thread.Frame) (*StackFrame, error) {
+	location := frame.Location
+	classInfo, ok := classes[location.ClassID]
+	if !ok {
+		var err error
+		classInfo, err = t.stackClassInfo(location.ClassID)
+		if err != nil {
+			return nil, err
+		}
+		classes[location.ClassID] = classInfo
+	}
+
+	stackFrame := &StackFrame{
+		FrameID:        frame.FrameID,
+		Location:       location,
+		ClassSignature: classInfo.signature,
+		ClassName:      common.SignatureToClassName(classInfo.signature),
+		SourceFile:     classInfo.sourceFile,
+		LineNumber:     -1,
+	}
+	if declared, ok := classInfo.methods.Find(location.MethodID); ok {
+		stackFrame.MethodName = declared.Name.String()
+		stackFrame.MethodSignature = declared.Signature.String()
+	}
+
+	lineTable, ok := classInfo.lineTables[location.MethodID]
+	if !ok {
+		var err error
+		lineTable, err = t.lineTable(location.ClassID, location.MethodID)
+		if err != nil {
+			return nil, err
+		}
+		classInfo.lineTables[location.MethodID] = lineTable
+	}
+	if lineTable != nil {
+		stackFrame.LineNumber = lineTable.LineForIndex(int64(location.Index))
+	}
+	return stackFrame, nil
+}
+
+func (t *threadCommands) stackClassInfo(refType basetypes.JWDPRefTypeID) (*stackClassInfo, error) {
+	refTypeCommands := t.ReferenceTypeCommands()
+	signature, err := refTypeCommands.Signature(refType)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := refTypeCommands.Methods(refType)
+	if err != nil {
+		return nil, err
+	}
+	sourceFile, err := refTypeCommands.SourceFile(refType)
+	if err != nil && !errors.Is(err, jdwp.ErrorAbsentInformation) {
+		return nil, err
+	}
+	return &stackClassInfo{
+		signature:  signature.String(),
+		sourceFile: sourceFile.String(),
+		methods:    methods,
+		lineTables: make(map[basetypes.JWDPMethodID]*method.LineTableReply),
+	}, nil
+}
+
+// lineTable returns nil without error for methods that have no line
+// information, such as native and abstract methods
+func (d *debuggercore) lineTable(refType basetypes.JWDPRefTypeID, methodID basetypes.JWDPMethodID) (*method.LineTableReply, error) {
+	lineTable, err := d.MethodCommands().LineTable(refType, methodID)
+	if errors.Is(err, jdwp.ErrorAbsentInformation) || errors.Is(err, jdwp.ErrorNativeMethod) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lineTable, nil
+}
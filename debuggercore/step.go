@@ -0,0 +1,124 @@
+package debuggercore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+)
+
+var (
+	// ErrThreadDeath is returned when the stepping thread ends before
+	// it stops
+	ErrThreadDeath = errors.New("thread died while stepping")
+	// ErrVMDeath is returned when the VM exits while a thread steps
+	ErrVMDeath = errors.New("VM died while stepping")
+)
+
+// StepResult is where a stepped thread stopped. If a breakpoint was hit
+// before the step completed, Breakpoint is set and RequestID is the
+// breakpoint's request
+type StepResult struct {
+	Thread     common.ThreadID
+	Location   common.Location
+	Breakpoint bool
+	RequestID  int32
+}
+
+func (s *StepResult) String() string {
+	if s.Breakpoint {
+		return fmt.Sprintf("breakpoint %d hit at %s", s.RequestID, s.Location.String())
+	}
+	return fmt.Sprintf("step completed at %s", s.Location.String())
+}
+
+func (t *threadCommands) StepInto(threadID common.ThreadID) (*StepResult, error) {
+	return t.Step(threadID, eventrequest.StepSizeLine, eventrequest.StepDepthInto)
+}
+
+func (t *threadCommands) StepOver(threadID common.ThreadID) (*StepResult, error) {
+	return t.Step(threadID, eventrequest.StepSizeLine, eventrequest.StepDepthOver)
+}
+
+func (t *threadCommands) StepOut(threadID common.ThreadID) (*StepResult, error) {
+	return t.Step(threadID, eventrequest.StepSizeLine, eventrequest.StepDepthOut)
+}
+
+func (t *threadCommands) Step(threadID common.ThreadID, size eventrequest.StepSize, depth eventrequest.StepDepth) (*StepResult, error) {
+	// Subscribe before the thread can run, so the event is not missed
+	subscription := t.Subscribe(event.KindSingleStep, event.KindBreakpoint, event.KindThreadDeath, event.KindVMDeath)
+	defer subscription.Unsubscribe()
+
+	// The VM only reports the thread ending if asked to
+	deathCommandData := eventrequest.New(event.KindThreadDeath, event.SuspendPolicyNone).
+		ThreadOnly(threadID)
+	deathRequestID, err := t.EventRequestCommands().Set(deathCommandData)
+	if err != nil {
+		return nil, err
+	}
+	cleanup := t.WithContext(context.WithoutCancel(t.ctx))
+	defer cleanup.EventRequestCommands().Clear(event.KindThreadDeath, deathRequestID)
+
+	setCommandData := eventrequest.New(event.KindSingleStep, event.SuspendPolicyEventThread).
+		Step(threadID, size, depth)
+	requestID, err := t.EventRequestCommands().Set(setCommandData)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.awaitStep(subscription, threadID, requestID)
+	// The VM allows only one step request per thread, so it is cleared
+	// whether or not the step completed, even if ctx is done
+	clearErr := cleanup.EventRequestCommands().Clear(event.KindSingleStep, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if clearErr != nil {
+		return nil, clearErr
+	}
+	return result, nil
+}
+
+func (t *threadCommands) awaitStep(subscription EventSubscription, threadID common.ThreadID, requestID int32) (*StepResult, error) {
+	if err := t.Resume(threadID); err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case ev, ok := <-subscription.Events():
+			if !ok {
+				return nil, jdwpsession.ErrSessionClosed
+			}
+			switch stopped := ev.Event.(type) {
+			case *event.SingleStep:
+				if ev.RequestID == requestID {
+					return &StepResult{
+						Thread:   stopped.Thread,
+						Location: stopped.Location,
+					}, nil
+				}
+			case *event.Breakpoint:
+				if stopped.Thread == threadID {
+					return &StepResult{
+						Thread:     stopped.Thread,
+						Location:   stopped.Location,
+						Breakpoint: true,
+						RequestID:  ev.RequestID,
+					}, nil
+				}
+			case *event.ThreadDeath:
+				if stopped.Thread == threadID {
+					return nil, ErrThreadDeath
+				}
+			case *event.VMDeath:
+				return nil, ErrVMDeath
+			}
+		case <-t.ctx.Done():
+			return nil, t.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package debuggercore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+)
+
+var (
+	steppingThread = common.ThreadID{ObjectID: 5}
+	otherThread    = common.ThreadID{ObjectID: 6}
+)
+
+func stepLocation(index uint64) common.Location {
+	return common.Location{
+		TypeTag:  basetypes.JWDPTypeTagClass,
+		ClassID:  basetypes.JWDPRefTypeID{RefTypeID: 0x40},
+		MethodID: basetypes.JWDPMethodID{MethodID: 1},
+		Index:    index,
+	}
+}
+
+type stepOutcome struct {
+	result *debuggercore.StepResult
+	err    error
+}
+
+// startStep steps over on steppingThread, returning once the thread has
+// been resumed, with the step request's ID and the outcome to come
+func startStep(t *testing.T, core debuggercore.DebuggerCore, srv *jdwptest.Server, requests *fakeEventRequests) (int32, <-chan stepOutcome) {
+	t.Helper()
+	outcome := make(chan stepOutcome, 1)
+	go func() {
+		result, err := core.ThreadCommands().StepOver(steppingThread)
+		outcome <- stepOutcome{result, err}
+	}()
+	waitFor(t, "the thread to be resumed", func() bool { return len(received(srv, thread.ResumeCommand)) > 0 })
+
+	steps := requests.active(event.KindSingleStep)
+	if len(steps) != 1 {
+		t.Fatalf("got %v step requests, want 1", len(steps))
+	}
+	request := requests.get(steps[0])
+	want := &eventrequest.StepModifier{Thread: steppingThread, Size: eventrequest.StepSizeLine, Depth: eventrequest.StepDepthOver}
+	if request.SuspendPolicy != event.SuspendPolicyEventThread || len(request.Modifiers) != 1 ||
+		*request.Modifiers[0].(*eventrequest.StepModifier) != *want {
+		t.Fatalf("step request: %v", request)
+	}
+	return steps[0], outcome
+}
+
+func awaitStepOutcome(t *testing.T, outcome <-chan stepOutcome) stepOutcome {
+	t.Helper()
+	select {
+	case got := <-outcome:
+		return got
+	case <-time.After(testTimeout):
+		t.Fatal("step did not return")
+	}
+	return stepOutcome{}
+}
+
+func startStepVM(t *testing.T) (debuggercore.DebuggerCore, *jdwptest.Server, *fakeEventRequests) {
+	var requests *fakeEventRequests
+	core, srv := startCore(t, func(srv *jdwptest.Server) {
+		requests = handleEventRequests(srv)
+		srv.HandleData(thread.ResumeCommand, nil)
+	})
+	return core, srv, requests
+}
+
+func TestStepCompletes(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	stepID, outcome := startStep(t, core, srv, requests)
+
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.SingleStep{RequestID: stepID, Thread: steppingThread, Location: stepLocation(4)})
+	got := awaitStepOutcome(t, outcome)
+	if got.err != nil {
+		t.Fatalf("StepOver: %v", got.err)
+	}
+	want := debuggercore.StepResult{Thread: steppingThread, Location: stepLocation(4)}
+	if *got.result != want {
+		t.Fatalf("StepOver: got %v, want %v", got.result, &want)
+	}
+	if steps := requests.active(event.KindSingleStep); len(steps) != 0 {
+		t.Fatalf("step requests %v not cleared", steps)
+	}
+}
+
+func TestStepStopsAtBreakpoint(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	_, outcome := startStep(t, core, srv, requests)
+
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.Breakpoint{RequestID: 42, Thread: steppingThread, Location: stepLocation(2)})
+	got := awaitStepOutcome(t, outcome)
+	if got.err != nil {
+		t.Fatalf("StepOver: %v", got.err)
+	}
+	want := debuggercore.StepResult{Thread: steppingThread, Location: stepLocation(2), Breakpoint: true, RequestID: 42}
+	if *got.result != want {
+		t.Fatalf("StepOver: got %v, want %v", got.result, &want)
+	}
+	if steps := requests.active(event.KindSingleStep); len(steps) != 0 {
+		t.Fatalf("step requests %v not cleared", steps)
+	}
+}
+
+func TestStepIgnoresOtherThreads(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	stepID, outcome := startStep(t, core, srv, requests)
+
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.Breakpoint{RequestID: 42, Thread: otherThread, Location: stepLocation(2)})
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.SingleStep{RequestID: stepID + 100, Thread: otherThread, Location: stepLocation(3)})
+	srv.SendEvents(event.SuspendPolicyNone, &event.ThreadDeath{RequestID: 43, Thread: otherThread})
+	select {
+	case got := <-outcome:
+		t.Fatalf("step returned on another thread's event: %+v", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	srv.SendEvents(event.SuspendPolicyEventThread,
+		&event.SingleStep{RequestID: stepID, Thread: steppingThread, Location: stepLocation(4)})
+	got := awaitStepOutcome(t, outcome)
+	if got.err != nil {
+		t.Fatalf("StepOver: %v", got.err)
+	}
+	if got.result.Breakpoint || got.result.Location != stepLocation(4) {
+		t.Fatalf("StepOver: got %v", got.result)
+	}
+}
+
+func TestStepClearedWhenContextCancelled(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, outcome := startStep(t, core.WithContext(ctx), srv, requests)
+
+	cancel()
+	got := awaitStepOutcome(t, outcome)
+	if !errors.Is(got.err, context.Canceled) {
+		t.Fatalf("StepOver: got %v, want context.Canceled", got.err)
+	}
+	if steps := requests.active(event.KindSingleStep); len(steps) != 0 {
+		t.Fatalf("step requests %v not cleared", steps)
+	}
+}
+
+func TestStepThreadDeath(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	_, outcome := startStep(t, core, srv, requests)
+
+	deaths := requests.active(event.KindThreadDeath)
+	if len(deaths) != 1 {
+		t.Fatalf("got %v thread death requests, want 1", len(deaths))
+	}
+	request := requests.get(deaths[0])
+	if request.SuspendPolicy != event.SuspendPolicyNone || len(request.Modifiers) != 1 ||
+		request.Modifiers[0].(*eventrequest.ThreadOnlyModifier).Thread != steppingThread {
+		t.Fatalf("thread death request: %v", request)
+	}
+
+	srv.SendEvents(event.SuspendPolicyNone, &event.ThreadDeath{RequestID: deaths[0], Thread: steppingThread})
+	got := awaitStepOutcome(t, outcome)
+	if !errors.Is(got.err, debuggercore.ErrThreadDeath) {
+		t.Fatalf("StepOver: got %v, want ErrThreadDeath", got.err)
+	}
+	if steps := requests.active(event.KindSingleStep); len(steps) != 0 {
+		t.Fatalf("step requests %v not cleared", steps)
+	}
+	if deaths := requests.active(event.KindThreadDeath); len(deaths) != 0 {
+		t.Fatalf("thread death requests %v not cleared", deaths)
+	}
+}
+
+func TestStepVMDeath(t *testing.T) {
+	core, srv, requests := startStepVM(t)
+	_, outcome := startStep(t, core, srv, requests)
+
+	srv.SendEvents(event.SuspendPolicyNone, &event.VMDeath{})
+	got := awaitStepOutcome(t, outcome)
+	if !errors.Is(got.err, debuggercore.ErrVMDeath) {
+		t.Fatalf("StepOver: got %v, want ErrVMDeath", got.err)
+	}
+	if steps := requests.active(event.KindSingleStep); len(steps) != 0 {
+		t.Fatalf("step requests %v not cleared", steps)
+	}
+}
@@ -0,0 +1,27 @@
+package debuggercore
+
+import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/stringref"
+)
+
+// StringReferenceCommands expose the StringReference commands
+type StringReferenceCommands interface {
+	Value(basetypes.JWDPObjectID) (string, error)
+}
+
+type stringReferenceCommands struct {
+	*debuggercore
+}
+
+func (s *stringReferenceCommands) Value(stringObject basetypes.JWDPObjectID) (string, error) {
+	valueCommandData := &stringref.ValueCommandData{
+		StringObject: stringObject,
+	}
+	var valueReply stringref.ValueReply
+	err := s.processCommand(stringref.ValueCommand, valueCommandData, &valueReply)
+	if err != nil {
+		return "", err
+	}
+	return valueReply.StringValue.String(), nil
+}
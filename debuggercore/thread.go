@@ -3,6 +3,7 @@ package debuggercore
 import (
 	"github.com/jquirke/jdwpgo/protocol/basetypes"
 	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
 	"github.com/jquirke/jdwpgo/protocol/thread"
 )
 
@@ -10,16 +11,219 @@ import (
 type ThreadCommands interface {
 	// Basics
 	Name(common.ThreadID) (basetypes.JDWPString, error)
+	ThreadGroup(common.ThreadID) (common.ThreadGroupID, error)
+	Status(common.ThreadID) (*thread.StatusReply, error)
+	// IsVirtual needs a JDK 21 or later VM; older ones reply
+	// ErrorNotImplemented
+	IsVirtual(common.ThreadID) (bool, error)
+	// Control
+	Suspend(common.ThreadID) error
+	Resume(common.ThreadID) error
+	SuspendCount(common.ThreadID) (int32, error)
+	Stop(common.ThreadID, basetypes.JWDPObjectID) error
+	Interrupt(common.ThreadID) error
+	ForceEarlyReturn(common.ThreadID, basetypes.JWDPValue) error
+	// Stack; a length of -1 fetches all frames from startFrame
+	Frames(threadID common.ThreadID, startFrame int32, length int32) (*thread.FramesReply, error)
+	FrameCount(common.ThreadID) (int32, error)
+	// StackTrace returns all frames of a suspended thread, innermost
+	// first, with class, method and line resolved
+	StackTrace(common.ThreadID) ([]StackFrame, error)
+	// Stepping resumes the suspended thread, waits for it to stop at
+	// the next line (or, for StepSizeMin, instruction) and returns where
+	// it stopped; this is a breakpoint if one fires first. Only the
+	// stepping thread is resumed, and it is left suspended again. If
+	// the thread ends or the VM exits first, Step returns
+	// ErrThreadDeath or ErrVMDeath
+	Step(threadID common.ThreadID, size eventrequest.StepSize, depth eventrequest.StepDepth) (*StepResult, error)
+	StepInto(common.ThreadID) (*StepResult, error)
+	StepOver(common.ThreadID) (*StepResult, error)
+	StepOut(common.ThreadID) (*StepResult, error)
+	// Monitors
+	OwnedMonitors(common.ThreadID) (*thread.OwnedMonitorsReply, error)
+	CurrentContendedMonitor(common.ThreadID) (basetypes.JWDPTaggedObjectID, error)
+	OwnedMonitorsStackDepthInfo(common.ThreadID) (*thread.OwnedMonitorsStackDepthInfoReply, error)
 }
 
-func (d *debuggercore) Name(threadID common.ThreadID) (basetypes.JDWPString, error) {
+type threadCommands struct {
+	*debuggercore
+}
+
+func (t *threadCommands) Name(threadID common.ThreadID) (basetypes.JDWPString, error) {
 	nameCommandData := &thread.NameCommandData{
 		ThreadID: threadID,
 	}
 	var nameReply thread.NameReply
-	err := d.processCommand(thread.NameCommand, nameCommandData, &nameReply)
+	err := t.processCommand(thread.NameCommand, nameCommandData, &nameReply)
 	if err != nil {
 		return basetypes.EmptyJWDPString(), err
 	}
 	return nameReply.ThreadName, nil
 }
+
+func (t *threadCommands) ThreadGroup(threadID common.ThreadID) (common.ThreadGroupID, error) {
+	threadGroupCommandData := &thread.ThreadGroupCommandData{
+		ThreadID: threadID,
+	}
+	var threadGroupReply thread.ThreadGroupReply
+	err := t.processCommand(thread.ThreadGroupCommand, threadGroupCommandData, &threadGroupReply)
+	if err != nil {
+		return common.ThreadGroupID{}, err
+	}
+	return threadGroupReply.Group, nil
+}
+
+func (t *threadCommands) IsVirtual(threadID common.ThreadID) (bool, error) {
+	isVirtualCommandData := &thread.IsVirtualCommandData{
+		ThreadID: threadID,
+	}
+	var isVirtualReply thread.IsVirtualReply
+	err := t.processCommand(thread.IsVirtualCommand, isVirtualCommandData, &isVirtualReply)
+	if err != nil {
+		return false, err
+	}
+	return isVirtualReply.IsVirtual, nil
+}
+
+func (t *threadCommands) Status(threadID common.ThreadID) (*thread.StatusReply, error) {
+	statusCommandData := &thread.StatusCommandData{
+		ThreadID: threadID,
+	}
+	var statusReply thread.StatusReply
+	err := t.processCommand(thread.StatusCommand, statusCommandData, &statusReply)
+	if err != nil {
+		return nil, err
+	}
+	return &statusReply, nil
+}
+
+func (t *threadCommands) Suspend(threadID common.ThreadID) error {
+	suspendCommandData := &thread.SuspendCommandData{
+		ThreadID: threadID,
+	}
+	err := t.processCommand(thread.SuspendCommand, suspendCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *threadCommands) Resume(threadID common.ThreadID) error {
+	resumeCommandData := &thread.ResumeCommandData{
+		ThreadID: threadID,
+	}
+	err := t.processCommand(thread.ResumeCommand, resumeCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *threadCommands) SuspendCount(threadID common.ThreadID) (int32, error) {
+	suspendCountCommandData := &thread.SuspendCountCommandData{
+		ThreadID: threadID,
+	}
+	var suspendCountReply thread.SuspendCountReply
+	err := t.processCommand(thread.SuspendCountCommand, suspendCountCommandData, &suspendCountReply)
+	if err != nil {
+		return 0, err
+	}
+	return suspendCountReply.SuspendCount, nil
+}
+
+func (t *threadCommands) Stop(threadID common.ThreadID, throwable basetypes.JWDPObjectID) error {
+	stopCommandData := &thread.StopCommandData{
+		ThreadID:  threadID,
+		Throwable: throwable,
+	}
+	err := t.processCommand(thread.StopCommand, stopCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *threadCommands) Interrupt(threadID common.ThreadID) error {
+	interruptCommandData := &thread.InterruptCommandData{
+		ThreadID: threadID,
+	}
+	err := t.processCommand(thread.InterruptCommand, interruptCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *threadCommands) ForceEarlyReturn(threadID common.ThreadID, value basetypes.JWDPValue) error {
+	forceEarlyReturnCommandData := &thread.ForceEarlyReturnCommandData{
+		ThreadID: threadID,
+		Value:    value,
+	}
+	err := t.processCommand(thread.ForceEarlyReturnCommand, forceEarlyReturnCommandData, nil)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *threadCommands) Frames(threadID common.ThreadID, startFrame int32, length int32) (*thread.FramesReply, error) {
+	framesCommandData := &thread.FramesCommandData{
+		ThreadID:   threadID,
+		StartFrame: startFrame,
+		Length:     length,
+	}
+	var framesReply thread.FramesReply
+	err := t.processCommand(thread.FramesCommand, framesCommandData, &framesReply)
+	if err != nil {
+		return nil, err
+	}
+	return &framesReply, nil
+}
+
+func (t *threadCommands) FrameCount(threadID common.ThreadID) (int32, error) {
+	frameCountCommandData := &thread.FrameCountCommandData{
+		ThreadID: threadID,
+	}
+	var frameCountReply thread.FrameCountReply
+	err := t.processCommand(thread.FrameCountCommand, frameCountCommandData, &frameCountReply)
+	if err != nil {
+		return 0, err
+	}
+	return frameCountReply.FrameCount, nil
+}
+
+func (t *threadCommands) OwnedMonitors(threadID common.ThreadID) (*thread.OwnedMonitorsReply, error) {
+	ownedMonitorsCommandData := &thread.OwnedMonitorsCommandData{
+		ThreadID: threadID,
+	}
+	var ownedMonitorsReply thread.OwnedMonitorsReply
+	err := t.processCommand(thread.OwnedMonitorsCommand, ownedMonitorsCommandData, &ownedMonitorsReply)
+	if err != nil {
+		return nil, err
+	}
+	return &ownedMonitorsReply, nil
+}
+
+func (t *threadCommands) CurrentContendedMonitor(threadID common.ThreadID) (basetypes.JWDPTaggedObjectID, error) {
+	currentContendedMonitorCommandData := &thread.CurrentContendedMonitorCommandData{
+		ThreadID: threadID,
+	}
+	var currentContendedMonitorReply thread.CurrentContendedMonitorReply
+	err := t.processCommand(thread.CurrentContendedMonitorCommand, currentContendedMonitorCommandData, &currentContendedMonitorReply)
+	if err != nil {
+		return basetypes.JWDPTaggedObjectID{}, err
+	}
+	return currentContendedMonitorReply.Monitor, nil
+}
+
+func (t *threadCommands) OwnedMonitorsStackDepthInfo(threadID common.ThreadID) (*thread.OwnedMonitorsStackDepthInfoReply, error) {
+	ownedMonitorsStackDepthInfoCommandData := &thread.OwnedMonitorsStackDepthInfoCommandData{
+		ThreadID: threadID,
+	}
+	var ownedMonitorsStackDepthInfoReply thread.OwnedMonitorsStackDepthInfoReply
+	err := t.processCommand(thread.OwnedMonitorsStackDepthInfoCommand, ownedMonitorsStackDepthInfoCommandData, &ownedMonitorsStackDepthInfoReply)
+	if err != nil {
+		return nil, err
+	}
+	return &ownedMonitorsStackDepthInfoReply, nil
+}
@@ -0,0 +1,38 @@
+package debuggercore_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+)
+
+func TestIsVirtual(t *testing.T) {
+	core, srv := startCore(t, func(srv *jdwptest.Server) {
+		srv.HandleData(thread.IsVirtualCommand, []byte{1})
+	})
+	isVirtual, err := core.ThreadCommands().IsVirtual(common.ThreadID{ObjectID: 0x22})
+	if err != nil {
+		t.Fatalf("IsVirtual: %v", err)
+	}
+	if !isVirtual {
+		t.Fatal("IsVirtual: got false")
+	}
+	sent := received(srv, thread.IsVirtualCommand)
+	if want := []byte{0, 0, 0, 0, 0, 0, 0, 0x22}; len(sent) != 1 || !bytes.Equal(sent[0].Data, want) {
+		t.Fatalf("IsVirtual command: got %v", sent)
+	}
+}
+
+func TestIsVirtualBeforeJDK21(t *testing.T) {
+	// the fake VM, like an older one, does not know the command
+	core, _ := startCore(t, nil)
+	_, err := core.ThreadCommands().IsVirtual(common.ThreadID{ObjectID: 0x22})
+	if !errors.Is(err, jdwp.ErrorNotImplemented) {
+		t.Fatalf("IsVirtual: got %v, want ErrorNotImplemented", err)
+	}
+}
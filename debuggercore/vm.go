@@ -1,12 +1,14 @@
 package debuggercore
 
 import (
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
 	"github.com/jquirke/jdwpgo/protocol/vm"
 )
 
 // VMCommands expose the VM commands
 type VMCommands interface {
 	// Class
+	ClassesBySignature(signature string) (*vm.ClassesBySignatureReply, error)
 	AllClasses() (*vm.AllClassReply, error)
 	// Thread ops
 	AllThreads() (*vm.AllThreadsReply, error)
@@ -42,6 +44,18 @@ func (d *debuggercore) AllClasses() (*vm.AllClassReply, error) {
 	return &allclassesReply, nil
 }
 
+func (d *debuggercore) ClassesBySignature(signature string) (*vm.ClassesBySignatureReply, error) {
+	classesBySignatureCommandData := vm.ClassesBySignatureCommandData{
+		Signature: basetypes.NewJDWPString(signature),
+	}
+	var classesBySignatureReply vm.ClassesBySignatureReply
+	err := d.processCommand(vm.ClassesBySignatureCommand, &classesBySignatureCommandData, &classesBySignatureReply)
+	if err != nil {
+		return nil, err
+	}
+	return &classesBySignatureReply, nil
+}
+
 func (d *debuggercore) AllThreads() (*vm.AllThreadsReply, error) {
 	var allthreadsReply vm.AllThreadsReply
 	err := d.processCommand(vm.AllThreadsCommand, nil, &allthreadsReply)
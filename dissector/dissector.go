@@ -0,0 +1,261 @@
+// Package dissector decodes JDWP packets into field by field dumps for
+// logging and debugging
+package dissector
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// Dissector decodes packets from a session with the given ID sizes
+type Dissector struct {
+	idSizes basetypes.IDSizes
+}
+
+// New creates a new dissector
+func New(idSizes basetypes.IDSizes) *Dissector {
+	return &Dissector{
+		idSizes: idSizes,
+	}
+}
+
+// Field is a single decoded field; structured values have Fields
+// instead of a Value
+type Field struct {
+	Name   string  `json:"name"`
+	Value  string  `json:"value,omitempty"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Dissection is a decoded packet
+type Dissection struct {
+	// Name is the qualified command name, such as "VirtualMachine.Version"
+	Name string `json:"name"`
+	// Reply is set if the packet is a reply to the named command
+	Reply     bool    `json:"reply"`
+	ErrorCode string  `json:"errorcode,omitempty"`
+	Fields    []Field `json:"fields,omitempty"`
+	// Error explains why the data could not be decoded; RawData then
+	// holds it in hex
+	Error   string `json:"error,omitempty"`
+	RawData string `json:"rawdata,omitempty"`
+}
+
+// DissectCommand decodes a command packet
+func (d *Dissector) DissectCommand(commandPacket *jdwpsession.CommandPacket) *Dissection {
+	cmd := jdwp.Command{Commandset: commandPacket.Commandset, Command: commandPacket.Command}
+	dissection := &Dissection{
+		Name: cmd.Name(),
+	}
+	spec, ok := registry[commandKey{cmd.Commandset, cmd.Command}]
+	switch {
+	case !ok:
+		dissection.undecoded(commandPacket.Data, "unsupported command")
+	case spec.decodeCommand != nil:
+		decoded, err := spec.decodeCommand(d.idSizes, commandPacket.Data)
+		dissection.decoded(commandPacket.Data, decoded, err)
+	default:
+		dissection.decode(d.idSizes, commandPacket.Data, spec.commandData)
+	}
+	return dissection
+}
+
+// DissectReply decodes a reply packet, given the command it answers
+func (d *Dissector) DissectReply(commandPacket *jdwpsession.CommandPacket, replyPacket *jdwpsession.ReplyPacket) *Dissection {
+	cmd := jdwp.Command{Commandset: commandPacket.Commandset, Command: commandPacket.Command}
+	dissection := &Dissection{
+		Name:  cmd.Name(),
+		Reply: true,
+	}
+	if replyPacket.Errorcode != uint16(jdwp.ErrorNone) {
+		dissection.ErrorCode = jdwp.ErrorCode(replyPacket.Errorcode).String()
+		if len(replyPacket.Data) > 0 {
+			dissection.undecoded(replyPacket.Data, "data in error reply")
+		}
+		return dissection
+	}
+	spec, ok := registry[commandKey{cmd.Commandset, cmd.Command}]
+	if !ok {
+		dissection.undecoded(replyPacket.Data, "unsupported command")
+		return dissection
+	}
+	dissection.decode(d.idSizes, replyPacket.Data, spec.reply)
+	return dissection
+}
+
+func (d *Dissection) decode(idSizes basetypes.IDSizes, data []byte, zero interface{}) {
+	if zero == nil {
+		if len(data) > 0 {
+			d.undecoded(data, "unexpected data")
+		}
+		return
+	}
+	value := reflect.New(reflect.TypeOf(zero))
+	err := idSizes.Unpack(data, value.Interface())
+	d.decoded(data, value.Interface(), err)
+}
+
+func (d *Dissection) decoded(data []byte, decoded interface{}, err error) {
+	if err != nil {
+		d.undecoded(data, err.Error())
+		return
+	}
+	d.Fields = fields(reflect.ValueOf(decoded))
+}
+
+func (d *Dissection) undecoded(data []byte, reason string) {
+	d.Error = reason
+	d.RawData = hex.EncodeToString(data)
+}
+
+// JSON returns the dissection as JSON
+func (d *Dissection) JSON() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+func (d *Dissection) String() string {
+	var builder strings.Builder
+	kind := "command"
+	if d.Reply {
+		kind = "reply"
+	}
+	builder.WriteString(fmt.Sprintf("%s %s", d.Name, kind))
+	if d.ErrorCode != "" {
+		builder.WriteString(fmt.Sprintf(" error=%s", d.ErrorCode))
+	}
+	builder.WriteString("\n")
+	writeFields(&builder, d.Fields, 1)
+	if d.Error != "" {
+		builder.WriteString(fmt.Sprintf("  undecoded (%s): %s\n", d.Error, d.RawData))
+	}
+	return builder.String()
+}
+
+func writeFields(builder *strings.Builder, fields []Field, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, field := range fields {
+		if field.Fields != nil {
+			builder.WriteString(fmt.Sprintf("%s%s:\n", indent, field.Name))
+			writeFields(builder, field.Fields, depth+1)
+		} else {
+			builder.WriteString(fmt.Sprintf("%s%s: %s\n", indent, field.Name, field.Value))
+		}
+	}
+}
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	unpackerType = reflect.TypeOf((*interface {
+		Unpack([]byte, binary.ByteOrder) ([]byte, error)
+	})(nil)).Elem()
+	jdwpStringType = reflect.TypeOf(basetypes.JDWPString{})
+)
+
+// fields walks a decoded struct (or pointer to one) into Fields
+func fields(value reflect.Value) []Field {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	result := make([]Field, 0)
+	if value.Kind() != reflect.Struct {
+		return result
+	}
+	for idx := 0; idx < value.NumField(); idx++ {
+		structField := value.Type().Field(idx)
+		if structField.PkgPath != "" {
+			continue
+		}
+		result = append(result, field(structField.Name, value.Field(idx)))
+	}
+	return result
+}
+
+func field(name string, value reflect.Value) Field {
+	if value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return Field{Name: name, Value: "nil"}
+		}
+		elem := value.Elem()
+		if value.Kind() == reflect.Interface {
+			// name the concrete type, such as the kind of an event
+			name = fmt.Sprintf("%s(%s)", name, reflect.Indirect(elem).Type().Name())
+		}
+		return field(name, elem)
+	}
+	if isLeaf(value) {
+		return Field{Name: name, Value: leafString(value)}
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		return Field{Name: name, Fields: fields(value)}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return Field{Name: name, Value: hex.EncodeToString(value.Bytes())}
+		}
+		elems := make([]Field, 0, value.Len())
+		for idx := 0; idx < value.Len(); idx++ {
+			elems = append(elems, field(fmt.Sprintf("[%d]", idx), value.Index(idx)))
+		}
+		return Field{Name: name, Fields: elems}
+	default:
+		return Field{Name: name, Value: fmt.Sprintf("%v", value.Interface())}
+	}
+}
+
+// isLeaf reports whether a value is printed whole rather than walked:
+// JDWP strings, IDs and values, which unpack themselves, and anything
+// other than structs and slices
+func isLeaf(value reflect.Value) bool {
+	valueType := value.Type()
+	if valueType == jdwpStringType {
+		return true
+	}
+	if valueType.Kind() == reflect.Struct {
+		return reflect.PtrTo(valueType).Implements(unpackerType)
+	}
+	return valueType.Kind() != reflect.Slice && valueType.Kind() != reflect.Array
+}
+
+func leafString(value reflect.Value) string {
+	addressable := reflect.New(value.Type())
+	addressable.Elem().Set(value)
+	var stringer fmt.Stringer
+	if value.Type().Implements(stringerType) {
+		stringer = value.Interface().(fmt.Stringer)
+	} else if addressable.Type().Implements(stringerType) {
+		stringer = addressable.Interface().(fmt.Stringer)
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if stringer != nil {
+			return stringer.String()
+		}
+		return fmt.Sprintf("%+v", value.Interface())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if stringer != nil {
+			// enums
+			return fmt.Sprintf("%s (%v)", stringer.String(), numeric(value))
+		}
+		return fmt.Sprintf("%v", numeric(value))
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
+
+func numeric(value reflect.Value) interface{} {
+	switch value.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return value.Int()
+	default:
+		return value.Uint()
+	}
+}
@@ -0,0 +1,160 @@
+package dissector_test
+
+import (
+	"testing"
+
+	"github.com/jquirke/jdwpgo/dissector"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+func TestDissect(t *testing.T) {
+	idSizes := basetypes.DefaultIDSizes()
+	idSizes.ObjectIDSize = 4
+	pack := func(v interface{}) []byte {
+		data, err := idSizes.Pack(v)
+		if err != nil {
+			t.Fatalf("Pack: %v", err)
+		}
+		return data
+	}
+	thread := common.ThreadID{ObjectID: 0x33}
+	composite, err := jdwptest.EncodeComposite(idSizes, event.SuspendPolicyEventThread,
+		&event.ThreadStart{RequestID: 3, Thread: thread},
+		&event.Breakpoint{RequestID: 4, Thread: thread, Location: common.Location{
+			TypeTag:  basetypes.JWDPTypeTagClass,
+			ClassID:  basetypes.JWDPRefTypeID{RefTypeID: 0x40},
+			MethodID: basetypes.JWDPMethodID{MethodID: 0x41},
+			Index:    7,
+		}})
+	if err != nil {
+		t.Fatalf("EncodeComposite: %v", err)
+	}
+	set, err := eventrequest.New(event.KindException, event.SuspendPolicyAll).ClassMatch("com.foo.*").PackIDSized(idSizes)
+	if err != nil {
+		t.Fatalf("PackIDSized: %v", err)
+	}
+	methods := &jdwpsession.CommandPacket{Commandset: reftype.MethodsCommand.Commandset, Command: reftype.MethodsCommand.Command}
+
+	tests := []struct {
+		name    string
+		command *jdwpsession.CommandPacket
+		reply   *jdwpsession.ReplyPacket
+		want    string
+	}{
+		{
+			name: "command with ID",
+			command: &jdwpsession.CommandPacket{
+				Commandset: methods.Commandset,
+				Command:    methods.Command,
+				Data:       pack(&reftype.MethodsCommandData{RefType: basetypes.JWDPRefTypeID{RefTypeID: 0x40}}),
+			},
+			want: "ReferenceType.Methods command\n" +
+				"  RefType: 0x40\n",
+		},
+		{
+			name:    "reply with slice",
+			command: methods,
+			reply: &jdwpsession.ReplyPacket{Data: pack(&reftype.MethodsReply{
+				NumDeclared: 1,
+				Declared: []reftype.Method{{
+					MethodID:  basetypes.JWDPMethodID{MethodID: 0x41},
+					Name:      basetypes.NewJDWPString("run"),
+					Signature: basetypes.NewJDWPString("()V"),
+					ModBits:   1,
+				}},
+			})},
+			want: "ReferenceType.Methods reply\n" +
+				"  NumDeclared: 1\n" +
+				"  Declared:\n" +
+				"    [0]:\n" +
+				"      MethodID: 0x41\n" +
+				"      Name: run\n" +
+				"      Signature: ()V\n" +
+				"      ModBits: 1\n",
+		},
+		{
+			name: "composite event",
+			command: &jdwpsession.CommandPacket{
+				Commandset: event.CompositeCommand.Commandset,
+				Command:    event.CompositeCommand.Command,
+				Data:       composite,
+			},
+			want: "Event.Composite command\n" +
+				"  SuspendPolicy: EventThread (1)\n" +
+				"  Events:\n" +
+				"    [0](ThreadStart):\n" +
+				"      RequestID: 3\n" +
+				"      Thread: ThreadID: 0x33\n" +
+				"    [1](Breakpoint):\n" +
+				"      RequestID: 4\n" +
+				"      Thread: ThreadID: 0x33\n" +
+				"      Location:\n" +
+				"        TypeTag: Class (1)\n" +
+				"        ClassID: 0x40\n" +
+				"        MethodID: 0x41\n" +
+				"        Index: 7\n",
+		},
+		{
+			name: "event request",
+			command: &jdwpsession.CommandPacket{
+				Commandset: eventrequest.SetCommand.Commandset,
+				Command:    eventrequest.SetCommand.Command,
+				Data:       set,
+			},
+			want: "EventRequest.Set command\n" +
+				"  EventKind: Exception (4)\n" +
+				"  SuspendPolicy: All (2)\n" +
+				"  Modifiers:\n" +
+				"    [0](ClassMatchModifier):\n" +
+				"      ClassPattern: com.foo.*\n",
+		},
+		{
+			name:    "unknown command",
+			command: &jdwpsession.CommandPacket{Commandset: 99, Command: 1, Data: []byte{1, 2}},
+			want: "Unknown(99).Unknown(1) command\n" +
+				"  undecoded (unsupported command): 0102\n",
+		},
+		{
+			name:    "unsupported command",
+			command: &jdwpsession.CommandPacket{Commandset: 1, Command: 11, Data: []byte{1, 2}},
+			want: "VirtualMachine.CreateString command\n" +
+				"  undecoded (unsupported command): 0102\n",
+		},
+		{
+			name:    "error reply",
+			command: &jdwpsession.CommandPacket{Commandset: vm.VersionCommand.Commandset, Command: vm.VersionCommand.Command},
+			reply:   &jdwpsession.ReplyPacket{Errorcode: 112},
+			want:    "VirtualMachine.Version reply error=VM_DEAD\n",
+		},
+		{
+			name:    "short data",
+			command: &jdwpsession.CommandPacket{Commandset: methods.Commandset, Command: methods.Command, Data: []byte{1}},
+			want: "ReferenceType.Methods command\n" +
+				"  undecoded (buffer too small: 1 < 8): 01\n",
+		},
+	}
+	d := dissector.New(idSizes)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dissection *dissector.Dissection
+			if tt.reply != nil {
+				dissection = d.DissectReply(tt.command, tt.reply)
+			} else {
+				dissection = d.DissectCommand(tt.command)
+			}
+			if got := dissection.String(); got != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if _, err := dissection.JSON(); err != nil {
+				t.Fatalf("JSON: %v", err)
+			}
+		})
+	}
+}
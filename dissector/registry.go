@@ -0,0 +1,139 @@
+package dissector
+
+import (
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/classtype"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/eventrequest"
+	"github.com/jquirke/jdwpgo/protocol/interfacetype"
+	"github.com/jquirke/jdwpgo/protocol/method"
+	"github.com/jquirke/jdwpgo/protocol/object"
+	"github.com/jquirke/jdwpgo/protocol/reftype"
+	"github.com/jquirke/jdwpgo/protocol/stackframe"
+	"github.com/jquirke/jdwpgo/protocol/stringref"
+	"github.com/jquirke/jdwpgo/protocol/thread"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+// decodeFunc decodes data that cannot be unpacked into a single struct
+type decodeFunc func(basetypes.IDSizes, []byte) (interface{}, error)
+
+// spec describes how to decode a command's data and reply. The struct
+// fields hold zero values of the types to unpack into, or nil
+type spec struct {
+	command       jdwp.Command
+	commandData   interface{}
+	reply         interface{}
+	decodeCommand decodeFunc
+}
+
+type commandKey struct {
+	commandset byte
+	command    byte
+}
+
+var specs = []spec{
+	// VirtualMachine
+	{command: vm.VersionCommand, reply: vm.VersionReply{}},
+	{command: vm.ClassesBySignatureCommand, commandData: vm.ClassesBySignatureCommandData{}, reply: vm.ClassesBySignatureReply{}},
+	{command: vm.AllClassesCommand, reply: vm.AllClassReply{}},
+	{command: vm.AllThreadsCommand, reply: vm.AllThreadsReply{}},
+	{command: vm.TopLevelThreadGroupsCommand, reply: vm.TopLevelThreadGroupsReply{}},
+	{command: vm.IDSizesCommand, reply: vm.IDSizesReply{}},
+	{command: vm.DisposeCommand},
+	{command: vm.SuspendCommand},
+	{command: vm.ResumeCommand},
+	{command: vm.ExitCommand, commandData: vm.ExitCommandData{}},
+	{command: vm.CapabilitiesCommand, reply: vm.CapabilitiesReply{}},
+	{command: vm.HoldEventsCommand},
+	{command: vm.ReleaseEventsCommand},
+	{command: vm.CapabilitiesNewCommand, reply: vm.CapabilitiesNewReply{}},
+	// ReferenceType
+	{command: reftype.SignatureCommand, commandData: reftype.SignatureCommandData{}, reply: reftype.SignatureReply{}},
+	{command: reftype.ClassLoaderCommand, commandData: reftype.ClassLoaderCommandData{}, reply: reftype.ClassLoaderReply{}},
+	{command: reftype.ModifiersCommand, commandData: reftype.ModifiersCommandData{}, reply: reftype.ModifiersReply{}},
+	{command: reftype.FieldsCommand, commandData: reftype.FieldsCommandData{}, reply: reftype.FieldsReply{}},
+	{command: reftype.MethodsCommand, commandData: reftype.MethodsCommandData{}, reply: reftype.MethodsReply{}},
+	{command: reftype.GetValuesCommand, commandData: reftype.GetValuesCommandData{}, reply: reftype.GetValuesReply{}},
+	{command: reftype.SourceFileCommand, commandData: reftype.SourceFileCommandData{}, reply: reftype.SourceFileReply{}},
+	{command: reftype.NestedTypesCommand, commandData: reftype.NestedTypesCommandData{}, reply: reftype.NestedTypesReply{}},
+	{command: reftype.StatusCommand, commandData: reftype.StatusCommandData{}, reply: reftype.StatusReply{}},
+	{command: reftype.InterfacesCommand, commandData: reftype.InterfacesCommandData{}, reply: reftype.InterfacesReply{}},
+	{command: reftype.ClassObjectCommand, commandData: reftype.ClassObjectCommandData{}, reply: reftype.ClassObjectReply{}},
+	{command: reftype.SourceDebugExtensionCommand, commandData: reftype.SourceDebugExtensionCommandData{}, reply: reftype.SourceDebugExtensionReply{}},
+	{command: reftype.SignatureWithGenericCommand, commandData: reftype.SignatureWithGenericCommandData{}, reply: reftype.SignatureWithGenericReply{}},
+	{command: reftype.FieldsWithGenericCommand, commandData: reftype.FieldsWithGenericCommandData{}, reply: reftype.FieldsWithGenericReply{}},
+	{command: reftype.MethodsWithGenericCommand, commandData: reftype.MethodsWithGenericCommandData{}, reply: reftype.MethodsWithGenericReply{}},
+	{command: reftype.InstancesCommand, commandData: reftype.InstancesCommandData{}, reply: reftype.InstancesReply{}},
+	{command: reftype.ClassFileVersionCommand, commandData: reftype.ClassFileVersionCommandData{}, reply: reftype.ClassFileVersionReply{}},
+	{command: reftype.ConstantPoolCommand, commandData: reftype.ConstantPoolCommandData{}, reply: reftype.ConstantPoolReply{}},
+	{command: reftype.ModuleCommand, commandData: reftype.ModuleCommandData{}, reply: reftype.ModuleReply{}},
+	// ClassType
+	{command: classtype.SuperclassCommand, commandData: classtype.SuperclassCommandData{}, reply: classtype.SuperclassReply{}},
+	{command: classtype.InvokeMethodCommand, commandData: classtype.InvokeMethodCommandData{}, reply: classtype.InvokeMethodReply{}},
+	{command: classtype.NewInstanceCommand, commandData: classtype.NewInstanceCommandData{}, reply: classtype.NewInstanceReply{}},
+	// InterfaceType
+	{command: interfacetype.InvokeMethodCommand, commandData: interfacetype.InvokeMethodCommandData{}, reply: interfacetype.InvokeMethodReply{}},
+	// Method
+	{command: method.LineTableCommand, commandData: method.LineTableCommandData{}, reply: method.LineTableReply{}},
+	{command: method.VariableTableCommand, commandData: method.VariableTableCommandData{}, reply: method.VariableTableReply{}},
+	{command: method.BytecodesCommand, commandData: method.BytecodesCommandData{}, reply: method.BytecodesReply{}},
+	{command: method.IsObsoleteCommand, commandData: method.IsObsoleteCommandData{}, reply: method.IsObsoleteReply{}},
+	{command: method.VariableTableWithGenericCommand, commandData: method.VariableTableWithGenericCommandData{}, reply: method.VariableTableWithGenericReply{}},
+	// ObjectReference
+	{command: object.ReferenceTypeCommand, commandData: object.ReferenceTypeCommandData{}, reply: object.ReferenceTypeReply{}},
+	{command: object.GetValuesCommand, commandData: object.GetValuesCommandData{}, reply: object.GetValuesReply{}},
+	{command: object.SetValuesCommand}, // values are untagged, so need the field types
+	{command: object.MonitorInfoCommand, commandData: object.MonitorInfoCommandData{}, reply: object.MonitorInfoReply{}},
+	{command: object.InvokeMethodCommand, commandData: object.InvokeMethodCommandData{}, reply: object.InvokeMethodReply{}},
+	{command: object.DisableCollectionCommand, commandData: object.DisableCollectionCommandData{}},
+	{command: object.EnableCollectionCommand, commandData: object.EnableCollectionCommandData{}},
+	{command: object.IsCollectedCommand, commandData: object.IsCollectedCommandData{}, reply: object.IsCollectedReply{}},
+	{command: object.ReferringObjectsCommand, commandData: object.ReferringObjectsCommandData{}, reply: object.ReferringObjectsReply{}},
+	// StringReference
+	{command: stringref.ValueCommand, commandData: stringref.ValueCommandData{}, reply: stringref.ValueReply{}},
+	// ThreadReference
+	{command: thread.NameCommand, commandData: thread.NameCommandData{}, reply: thread.NameReply{}},
+	{command: thread.SuspendCommand, commandData: thread.SuspendCommandData{}},
+	{command: thread.ResumeCommand, commandData: thread.ResumeCommandData{}},
+	{command: thread.StatusCommand, commandData: thread.StatusCommandData{}, reply: thread.StatusReply{}},
+	{command: thread.ThreadGroupCommand, commandData: thread.ThreadGroupCommandData{}, reply: thread.ThreadGroupReply{}},
+	{command: thread.FramesCommand, commandData: thread.FramesCommandData{}, reply: thread.FramesReply{}},
+	{command: thread.FrameCountCommand, commandData: thread.FrameCountCommandData{}, reply: thread.FrameCountReply{}},
+	{command: thread.OwnedMonitorsCommand, commandData: thread.OwnedMonitorsCommandData{}, reply: thread.OwnedMonitorsReply{}},
+	{command: thread.CurrentContendedMonitorCommand, commandData: thread.CurrentContendedMonitorCommandData{}, reply: thread.CurrentContendedMonitorReply{}},
+	{command: thread.StopCommand, commandData: thread.StopCommandData{}},
+	{command: thread.InterruptCommand, commandData: thread.InterruptCommandData{}},
+	{command: thread.SuspendCountCommand, commandData: thread.SuspendCountCommandData{}, reply: thread.SuspendCountReply{}},
+	{command: thread.OwnedMonitorsStackDepthInfoCommand, commandData: thread.OwnedMonitorsStackDepthInfoCommandData{}, reply: thread.OwnedMonitorsStackDepthInfoReply{}},
+	{command: thread.ForceEarlyReturnCommand, commandData: thread.ForceEarlyReturnCommandData{}},
+	{command: thread.IsVirtualCommand, commandData: thread.IsVirtualCommandData{}, reply: thread.IsVirtualReply{}},
+	// EventRequest
+	{command: eventrequest.SetCommand, decodeCommand: decodeSetCommandData, reply: eventrequest.SetReply{}},
+	{command: eventrequest.ClearCommand, commandData: eventrequest.ClearCommandData{}},
+	{command: eventrequest.ClearAllBreakpointsCommand},
+	// StackFrame
+	{command: stackframe.GetValuesCommand, commandData: stackframe.GetValuesCommandData{}, reply: stackframe.GetValuesReply{}},
+	{command: stackframe.SetValuesCommand, commandData: stackframe.SetValuesCommandData{}},
+	{command: stackframe.ThisObjectCommand, commandData: stackframe.ThisObjectCommandData{}, reply: stackframe.ThisObjectReply{}},
+	{command: stackframe.PopFramesCommand, commandData: stackframe.PopFramesCommandData{}},
+	// Event
+	{command: event.CompositeCommand, decodeCommand: decodeComposite},
+}
+
+var registry = make(map[commandKey]*spec)
+
+func init() {
+	for idx := range specs {
+		registry[commandKey{specs[idx].command.Commandset, specs[idx].command.Command}] = &specs[idx]
+	}
+}
+
+func decodeSetCommandData(idSizes basetypes.IDSizes, data []byte) (interface{}, error) {
+	return eventrequest.DecodeSetCommandData(idSizes, data)
+}
+
+func decodeComposite(idSizes basetypes.IDSizes, data []byte) (interface{}, error) {
+	return event.DecodeComposite(idSizes, data)
+}
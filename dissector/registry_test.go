@@ -0,0 +1,64 @@
+package dissector
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// commandsets maps the protocol packages used in registry.go to the
+// command sets they implement
+var commandsets = map[string]string{
+	"vm":            "VirtualMachine",
+	"reftype":       "ReferenceType",
+	"classtype":     "ClassType",
+	"interfacetype": "InterfaceType",
+	"method":        "Method",
+	"object":        "ObjectReference",
+	"stringref":     "StringReference",
+	"thread":        "ThreadReference",
+	"eventrequest":  "EventRequest",
+	"stackframe":    "StackFrame",
+	"event":         "Event",
+}
+
+// TestRegistryNames checks that every spec's command is the one its
+// variable is named for, such as vm.VersionCommand being
+// "VirtualMachine.Version"
+func TestRegistryNames(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "registry.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parsing registry.go: %v", err)
+	}
+	var names []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		keyValue, ok := node.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		if key, ok := keyValue.Key.(*ast.Ident); !ok || key.Name != "command" {
+			return true
+		}
+		selector := keyValue.Value.(*ast.SelectorExpr)
+		pkg := selector.X.(*ast.Ident).Name
+		commandset, ok := commandsets[pkg]
+		if !ok {
+			t.Errorf("%v.%v: unknown command set", pkg, selector.Sel.Name)
+		}
+		names = append(names, commandset+"."+strings.TrimSuffix(selector.Sel.Name, "Command"))
+		return false
+	})
+	if len(names) != len(specs) {
+		t.Fatalf("found %v commands in registry.go, want %v", len(names), len(specs))
+	}
+	for idx, spec := range specs {
+		if got := spec.command.Name(); got != names[idx] {
+			t.Errorf("spec %v: command is %v, want %v", idx, got, names[idx])
+		}
+	}
+	if len(registry) != len(specs) {
+		t.Errorf("registry has %v commands, want %v: duplicate specs", len(registry), len(specs))
+	}
+}
@@ -0,0 +1,103 @@
+// Package queue provides the FIFO queue that sits between a reader of
+// the JDWP connection and slower consumers, so that the reader never
+// waits on them unless asked to
+package queue
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Policy decides what Push does when the queue is at its limit
+type Policy int
+
+const (
+	// Block - wait for room
+	Block Policy = iota
+	// DropOldest - discard the oldest queued item
+	DropOldest
+	// DropNewest - discard the item being pushed
+	DropNewest
+	// Fail - return an error
+	Fail
+)
+
+// Queue is a FIFO queue, unbounded unless given a limit, that is safe
+// for concurrent use
+type Queue struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	items  []interface{}
+	limit  int
+	policy Policy
+	closed bool
+}
+
+// New creates a queue. A limit of 0 means no limit, in which case
+// policy is never applied
+func New(limit int, policy Policy) *Queue {
+	q := &Queue{
+		limit:  limit,
+		policy: policy,
+	}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+// Push queues an item, applying the policy if the queue is at its
+// limit, and reports whether an item was dropped to make room. It only
+// blocks under Block. Items pushed after Close are discarded
+func (q *Queue) Push(item interface{}) (dropped bool, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for q.limit > 0 && len(q.items) >= q.limit && !q.closed {
+		switch q.policy {
+		case DropOldest:
+			q.items[0] = nil
+			q.items = q.items[1:]
+			dropped = true
+		case DropNewest:
+			return true, nil
+		case Fail:
+			return false, fmt.Errorf("queue overflow: %v items queued", len(q.items))
+		default:
+			q.cond.Wait()
+		}
+	}
+	if q.closed {
+		return dropped, nil
+	}
+	q.items = append(q.items, item)
+	q.cond.Broadcast()
+	return dropped, nil
+}
+
+// Pop waits for the next item. Once the queue is closed the items
+// still queued are returned, then ok is false
+func (q *Queue) Pop() (item interface{}, ok bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.items) == 0 {
+		if q.closed {
+			return nil, false
+		}
+		q.cond.Wait()
+	}
+	item = q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	q.cond.Broadcast()
+	return item, true
+}
+
+// Close stops the queue accepting items, discarding those still queued
+// if discard is set, and wakes everything waiting on it
+func (q *Queue) Close(discard bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.closed = true
+	if discard {
+		q.items = nil
+	}
+	q.cond.Broadcast()
+}
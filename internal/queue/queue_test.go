@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func pushAll(t *testing.T, q *Queue, items ...int) {
+	t.Helper()
+	for _, item := range items {
+		if _, err := q.Push(item); err != nil {
+			t.Fatalf("Push(%v): %v", item, err)
+		}
+	}
+}
+
+func popAll(q *Queue) []int {
+	q.Close(false)
+	var items []int
+	for {
+		item, ok := q.Pop()
+		if !ok {
+			return items
+		}
+		items = append(items, item.(int))
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUnbounded(t *testing.T) {
+	q := New(0, Fail)
+	pushAll(t, q, 1, 2, 3, 4, 5)
+	if got := popAll(q); !equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		policy      Policy
+		want        []int
+		wantDropped bool
+		wantErr     bool
+	}{
+		{DropOldest, []int{2, 3}, true, false},
+		{DropNewest, []int{1, 2}, true, false},
+		{Fail, []int{1, 2}, false, true},
+	}
+	for _, tt := range tests {
+		q := New(2, tt.policy)
+		pushAll(t, q, 1, 2)
+		dropped, err := q.Push(3)
+		if dropped != tt.wantDropped || (err != nil) != tt.wantErr {
+			t.Fatalf("policy %v: Push got dropped %v, err %v", tt.policy, dropped, err)
+		}
+		if got := popAll(q); !equal(got, tt.want) {
+			t.Fatalf("policy %v: got %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestBlockWaitsForRoom(t *testing.T) {
+	q := New(1, Block)
+	pushAll(t, q, 1)
+	pushed := make(chan struct{})
+	go func() {
+		q.Push(2)
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("Push did not block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if item, _ := q.Pop(); item != 1 {
+		t.Fatalf("Pop: got %v", item)
+	}
+	<-pushed
+	if got := popAll(q); !equal(got, []int{2}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	q := New(1, Block)
+	popped := make(chan bool)
+	go func() {
+		_, ok := q.Pop()
+		popped <- ok
+	}()
+	pushAll(t, q, 1)
+	if ok := <-popped; !ok {
+		t.Fatal("Pop: no item")
+	}
+
+	go func() {
+		_, ok := q.Pop()
+		popped <- ok
+	}()
+	q.Close(false)
+	if ok := <-popped; ok {
+		t.Fatal("Pop returned an item from a closed, empty queue")
+	}
+}
+
+func TestCloseDiscard(t *testing.T) {
+	q := New(0, Block)
+	pushAll(t, q, 1, 2)
+	q.Close(true)
+	if _, ok := q.Pop(); ok {
+		t.Fatal("Pop returned a discarded item")
+	}
+	if dropped, err := q.Push(3); dropped || err != nil {
+		t.Fatalf("Push after Close: %v %v", dropped, err)
+	}
+	if _, ok := q.Pop(); ok {
+		t.Fatal("Pop returned an item pushed after Close")
+	}
+}
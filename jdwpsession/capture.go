@@ -0,0 +1,213 @@
+package jdwpsession
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Capture file format
+//
+// A capture starts with the 8 byte magic "JDWPCAP1", followed by one
+// record per packet in the order the session sent or received them:
+//
+//	direction  1 byte   0 = debugger to VM, 1 = VM to debugger
+//	timestamp  8 bytes  big endian signed nanoseconds since the Unix epoch
+//	packet     n bytes  the packet exactly as on the wire, starting with
+//	                    its 4 byte big endian length (which includes the
+//	                    11 byte header), then id, flags, and either
+//	                    commandset and command, or errorcode, then data
+//
+// The handshake is not captured.
+const captureMagic = "JDWPCAP1"
+
+// Direction represents which way a captured packet travelled
+type Direction byte
+
+const (
+	// DirectionOutbound - debugger to VM
+	DirectionOutbound Direction = 0
+	// DirectionInbound - VM to debugger
+	DirectionInbound Direction = 1
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirectionOutbound:
+		return "out"
+	case DirectionInbound:
+		return "in"
+	default:
+		return "unknown"
+	}
+}
+
+// CaptureRecord represents a single captured packet; exactly one of
+// CommandPacket and ReplyPacket is set
+type CaptureRecord struct {
+	Direction     Direction
+	Timestamp     time.Time
+	ID            uint32
+	Flags         byte
+	CommandPacket *CommandPacket
+	ReplyPacket   *ReplyPacket
+}
+
+func (c *CaptureRecord) String() string {
+	if c.CommandPacket != nil {
+		return fmt.Sprintf("%v %v id=%v flags=%x commandpacket=%v", c.Timestamp.Format(time.RFC3339Nano),
+			c.Direction, c.ID, c.Flags, c.CommandPacket)
+	}
+	return fmt.Sprintf("%v %v id=%v flags=%x replypacket=%v", c.Timestamp.Format(time.RFC3339Nano),
+		c.Direction, c.ID, c.Flags, c.ReplyPacket)
+}
+
+// CaptureWriter writes a capture stream; it is safe for concurrent use
+type CaptureWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// NewCaptureWriter writes the capture magic to w and returns a writer
+// for the records
+func NewCaptureWriter(w io.Writer) (*CaptureWriter, error) {
+	if _, err := io.WriteString(w, captureMagic); err != nil {
+		return nil, err
+	}
+	return &CaptureWriter{w: w}, nil
+}
+
+// WriteRecord appends a record to the capture
+func (c *CaptureWriter) WriteRecord(record *CaptureRecord) error {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(record.Direction))
+	binary.Write(&buf, binary.BigEndian, record.Timestamp.UnixNano())
+	buf.Write(encodePacket(record.ID, record.Flags, record.CommandPacket, record.ReplyPacket))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+func (c *CaptureWriter) capture(direction Direction, wrappedPacket *WrappedPacket) error {
+	return c.WriteRecord(&CaptureRecord{
+		Direction:     direction,
+		Timestamp:     time.Now(),
+		ID:            wrappedPacket.id,
+		Flags:         wrappedPacket.flags,
+		CommandPacket: wrappedPacket.commandPacket,
+		ReplyPacket:   wrappedPacket.replyPacket,
+	})
+}
+
+// CaptureReader reads a capture stream
+type CaptureReader struct {
+	r io.Reader
+}
+
+// NewCaptureReader checks the capture magic and returns a reader for
+// the records that follow
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != captureMagic {
+		return nil, fmt.Errorf("not a JDWP capture: bad magic %q", magic)
+	}
+	return &CaptureReader{r: r}, nil
+}
+
+// ReadRecord returns the next record, or io.EOF at the end of the capture
+func (c *CaptureReader) ReadRecord() (*CaptureRecord, error) {
+	var record CaptureRecord
+	var direction [1]byte
+	if _, err := io.ReadFull(c.r, direction[:]); err != nil {
+		return nil, err
+	}
+	record.Direction = Direction(direction[0])
+	if record.Direction != DirectionOutbound && record.Direction != DirectionInbound {
+		return nil, fmt.Errorf("bad capture direction: %v", direction[0])
+	}
+	var timestamp int64
+	if err := binary.Read(c.r, binary.BigEndian, &timestamp); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	record.Timestamp = time.Unix(0, timestamp)
+	wrappedPacket, err := decodePacket(c.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	record.ID = wrappedPacket.id
+	record.Flags = wrappedPacket.flags
+	record.CommandPacket = wrappedPacket.commandPacket
+	record.ReplyPacket = wrappedPacket.replyPacket
+	return &record, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// encodePacket encodes a packet in wire format
+func encodePacket(id uint32, flags byte, commandPacket *CommandPacket, replyPacket *ReplyPacket) []byte {
+	var buf bytes.Buffer
+	var data []byte
+	if commandPacket != nil {
+		data = commandPacket.Data
+	} else {
+		data = replyPacket.Data
+	}
+	binary.Write(&buf, binary.BigEndian, uint32(headerBytes+len(data)))
+	binary.Write(&buf, binary.BigEndian, id)
+	buf.WriteByte(flags)
+	if commandPacket != nil {
+		buf.WriteByte(commandPacket.Commandset)
+		buf.WriteByte(commandPacket.Command)
+	} else {
+		binary.Write(&buf, binary.BigEndian, replyPacket.Errorcode)
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// decodePacket decodes a single packet in wire format
+func decodePacket(r io.Reader) (*WrappedPacket, error) {
+	header := make([]byte, headerBytes)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size < headerBytes {
+		return nil, fmt.Errorf("packet too small: %v", size)
+	}
+	wrappedPacket := &WrappedPacket{
+		id:    binary.BigEndian.Uint32(header[4:8]),
+		flags: header[8],
+	}
+	data := make([]byte, size-headerBytes)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if wrappedPacket.flags&flagsReplyPacket == flagsReplyPacket {
+		wrappedPacket.replyPacket = &ReplyPacket{
+			Errorcode: binary.BigEndian.Uint16(header[9:11]),
+			Data:      data,
+		}
+	} else {
+		wrappedPacket.commandPacket = &CommandPacket{
+			Commandset: header[9],
+			Command:    header[10],
+			Data:       data,
+		}
+	}
+	return wrappedPacket, nil
+}
@@ -0,0 +1,273 @@
+package jdwpsession_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+var captureRecords = []*jdwpsession.CaptureRecord{
+	{
+		Direction:     jdwpsession.DirectionOutbound,
+		Timestamp:     time.Unix(0, 0x0102030405060708),
+		ID:            7,
+		CommandPacket: &jdwpsession.CommandPacket{Commandset: 1, Command: 1, Data: []byte{}},
+	},
+	{
+		Direction:   jdwpsession.DirectionInbound,
+		Timestamp:   time.Unix(0, 0x0102030405060709),
+		ID:          7,
+		Flags:       0x80,
+		ReplyPacket: &jdwpsession.ReplyPacket{Errorcode: 0x0203, Data: []byte{0xAA, 0xBB}},
+	},
+	{
+		Direction:     jdwpsession.DirectionInbound,
+		Timestamp:     time.Unix(0, 0x010203040506070A),
+		ID:            1,
+		CommandPacket: &jdwpsession.CommandPacket{Commandset: 64, Command: 100, Data: []byte{0xCC}},
+	},
+}
+
+// captureBytes is captureRecords in the documented format
+var captureBytes = concat(
+	[]byte("JDWPCAP1"),
+	[]byte{0},
+	[]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	[]byte{0, 0, 0, 11, 0, 0, 0, 7, 0, 1, 1},
+	[]byte{1},
+	[]byte{1, 2, 3, 4, 5, 6, 7, 9},
+	[]byte{0, 0, 0, 13, 0, 0, 0, 7, 0x80, 2, 3, 0xAA, 0xBB},
+	[]byte{1},
+	[]byte{1, 2, 3, 4, 5, 6, 7, 10},
+	[]byte{0, 0, 0, 12, 0, 0, 0, 1, 0, 64, 100, 0xCC},
+)
+
+func concat(parts ...[]byte) []byte {
+	var all []byte
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	return all
+}
+
+func TestCaptureFormat(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := jdwpsession.NewCaptureWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewCaptureWriter: %v", err)
+	}
+	for _, record := range captureRecords {
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatalf("WriteRecord: %v", err)
+		}
+	}
+	if !bytes.Equal(buf.Bytes(), captureBytes) {
+		t.Fatalf("capture:\n got % X\nwant % X", buf.Bytes(), captureBytes)
+	}
+}
+
+func TestCaptureRoundTrip(t *testing.T) {
+	reader, err := jdwpsession.NewCaptureReader(bytes.NewReader(captureBytes))
+	if err != nil {
+		t.Fatalf("NewCaptureReader: %v", err)
+	}
+	for _, want := range captureRecords {
+		got, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("ReadRecord: %v", err)
+		}
+		if !got.Timestamp.Equal(want.Timestamp) {
+			t.Fatalf("Timestamp: got %v, want %v", got.Timestamp, want.Timestamp)
+		}
+		gotCopy, wantCopy := *got, *want
+		gotCopy.Timestamp, wantCopy.Timestamp = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(gotCopy, wantCopy) {
+			t.Fatalf("ReadRecord: got %v, want %v", got, want)
+		}
+	}
+	if _, err := reader.ReadRecord(); err != io.EOF {
+		t.Fatalf("ReadRecord at end: got %v, want io.EOF", err)
+	}
+}
+
+func TestCaptureReaderErrors(t *testing.T) {
+	record := captureBytes[8 : 8+1+8+11]
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"bad magic", []byte("JDWPCAP2"), "bad magic"},
+		{"short magic", []byte("JDWP"), "unexpected EOF"},
+		{"bad direction", concat([]byte("JDWPCAP1"), []byte{2}, record[1:]), "bad capture direction: 2"},
+		{"truncated timestamp", concat([]byte("JDWPCAP1"), record[:5]), "unexpected EOF"},
+		{"truncated header", concat([]byte("JDWPCAP1"), record[:15]), "unexpected EOF"},
+		{"truncated data", concat([]byte("JDWPCAP1"), captureBytes[8+1+8+11:8+2*(1+8)+11+12]), "unexpected EOF"},
+		{"packet too small", concat([]byte("JDWPCAP1"), record[:9], []byte{0, 0, 0, 5, 0, 0, 0, 1, 0, 1, 1}), "packet too small: 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := jdwpsession.NewCaptureReader(bytes.NewReader(tt.data))
+			if err == nil {
+				_, err = reader.ReadRecord()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// debugSession is what TestCaptureReplay does against both the fake VM
+// and the replay of its capture
+func debugSession(t *testing.T, session jdwpsession.Session, sendEvent func()) (*vm.VersionReply, []common.ThreadID, *debuggercore.Event) {
+	t.Helper()
+	core, err := debuggercore.NewFromJWDPSession(session)
+	if err != nil {
+		t.Fatalf("NewFromJWDPSession: %v", err)
+	}
+	subscription := core.EventCommands().Subscribe()
+	defer subscription.Unsubscribe()
+	version, err := core.VMCommands().Version()
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	sendEvent()
+	var ev *debuggercore.Event
+	select {
+	case ev = <-subscription.Events():
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for event")
+	}
+	threads, err := core.VMCommands().AllThreads()
+	if err != nil {
+		t.Fatalf("AllThreads: %v", err)
+	}
+	if err := session.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	return version, threads.Threads, ev
+}
+
+func TestCaptureReplay(t *testing.T) {
+	srv, conn := jdwptest.NewPipe()
+	srv.IDSizes.ObjectIDSize = 4
+	srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{
+		Description: basetypes.NewJDWPString("fake"),
+		JwdpMajor:   17,
+		VMVersion:   basetypes.NewJDWPString("17.0.1"),
+		VMName:      basetypes.NewJDWPString("FakeVM"),
+	})
+	srv.HandleStruct(vm.AllThreadsCommand, &vm.AllThreadsReply{
+		NumThreads: 2,
+		Threads:    []common.ThreadID{{ObjectID: 0x11}, {ObjectID: 0x22}},
+	})
+	srv.Start()
+	defer srv.Close()
+	var capture bytes.Buffer
+	captureWriter, err := jdwpsession.NewCaptureWriter(&capture)
+	if err != nil {
+		t.Fatalf("NewCaptureWriter: %v", err)
+	}
+	session := jdwpsession.New(conn, jdwpsession.WithCapture(captureWriter))
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	liveVersion, liveThreads, liveEvent := debugSession(t, session, func() {
+		srv.SendEvents(event.SuspendPolicyNone, &event.ThreadStart{RequestID: 3, Thread: common.ThreadID{ObjectID: 0x33}})
+	})
+
+	replayConn, status, err := jdwpsession.NewReplayConn(bytes.NewReader(capture.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReplayConn: %v", err)
+	}
+	replayed := jdwpsession.New(replayConn)
+	if err := replayed.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// the event is replayed where it was captured
+	version, threads, ev := debugSession(t, replayed, func() {})
+	select {
+	case <-status.Done():
+	case <-time.After(testTimeout):
+		t.Fatal("replay did not finish")
+	}
+	if err := status.Err(); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	// IDSizes, Version, the event, AllThreads and Dispose, each with
+	// its reply but for the event
+	if status.Records() != 9 {
+		t.Fatalf("replayed %v records, want 9", status.Records())
+	}
+	if !reflect.DeepEqual(version, liveVersion) || !reflect.DeepEqual(threads, liveThreads) {
+		t.Fatalf("replay: got %v %v, want %v %v", version, threads, liveVersion, liveThreads)
+	}
+	ev.Composite, liveEvent.Composite = nil, nil
+	if !reflect.DeepEqual(ev, liveEvent) {
+		t.Fatalf("replay: got event %v, want %v", ev, liveEvent)
+	}
+}
+
+func TestReplayDeviation(t *testing.T) {
+	replayConn, status, err := jdwpsession.NewReplayConn(bytes.NewReader(captureBytes))
+	if err != nil {
+		t.Fatalf("NewReplayConn: %v", err)
+	}
+	session := jdwpsession.New(replayConn)
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer session.Stop()
+	// the capture expects Version (1/1)
+	_, err = sendCommand(t, session, vm.AllThreadsCommand)
+	if !errors.Is(err, jdwpsession.ErrSessionClosed) {
+		t.Fatalf("got %v, want ErrSessionClosed", err)
+	}
+	<-status.Done()
+	if err := status.Err(); err == nil || !strings.Contains(err.Error(), "record 0: debugger sent") {
+		t.Fatalf("replay: got %v, want deviation error", err)
+	}
+}
+
+func TestReplayOutboundReplyUnsupported(t *testing.T) {
+	var capture bytes.Buffer
+	captureWriter, _ := jdwpsession.NewCaptureWriter(&capture)
+	captureWriter.WriteRecord(&jdwpsession.CaptureRecord{
+		Direction:   jdwpsession.DirectionOutbound,
+		ID:          1,
+		Flags:       0x80,
+		ReplyPacket: &jdwpsession.ReplyPacket{},
+	})
+	replayConn, status, err := jdwpsession.NewReplayConn(&capture)
+	if err != nil {
+		t.Fatalf("NewReplayConn: %v", err)
+	}
+	session := jdwpsession.New(replayConn)
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer session.Stop()
+	<-status.Done()
+	if err := status.Err(); err == nil || !strings.Contains(err.Error(), "outbound replies is not supported") {
+		t.Fatalf("replay: got %v", err)
+	}
+}
+
+func TestReplayBadMagic(t *testing.T) {
+	if _, _, err := jdwpsession.NewReplayConn(strings.NewReader("not a capture")); err == nil {
+		t.Fatal("NewReplayConn: expected error")
+	}
+}
@@ -0,0 +1,112 @@
+package jdwpsession
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/internal/queue"
+)
+
+// OverflowPolicy decides what happens to a command packet from the VM
+// when the queue in front of JvmCommandPacketChannel is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock - stop reading from the connection until there is
+	// room. Replies to outstanding commands stall meanwhile
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest - discard the oldest queued packet
+	OverflowDropOldest
+	// OverflowDropNewest - discard the packet just received
+	OverflowDropNewest
+	// OverflowFail - shut the session down with an error
+	OverflowFail
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case OverflowBlock:
+		return "Block"
+	case OverflowDropOldest:
+		return "DropOldest"
+	case OverflowDropNewest:
+		return "DropNewest"
+	case OverflowFail:
+		return "Fail"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(o))
+	}
+}
+
+// queuePolicy maps an OverflowPolicy to the queue's own
+func (o OverflowPolicy) queuePolicy() queue.Policy {
+	switch o {
+	case OverflowDropOldest:
+		return queue.DropOldest
+	case OverflowDropNewest:
+		return queue.DropNewest
+	case OverflowFail:
+		return queue.Fail
+	default:
+		return queue.Block
+	}
+}
+
+// commandQueue sits between the rxLoop and the JVM command channel so
+// that a slow consumer of events never holds up dispatch of replies.
+// It is unbounded unless given a limit
+type commandQueue struct {
+	queue *queue.Queue
+}
+
+func newCommandQueue(limit int, policy OverflowPolicy) *commandQueue {
+	return &commandQueue{queue: queue.New(limit, policy.queuePolicy())}
+}
+
+// push queues a packet, applying the overflow policy if the queue is
+// at its limit, and reports whether a packet was dropped to make room.
+// It only blocks under OverflowBlock
+func (q *commandQueue) push(packet *CommandPacket) (dropped bool, err error) {
+	dropped, err = q.queue.Push(packet)
+	if err != nil {
+		return false, fmt.Errorf("JVM command %v", err)
+	}
+	return dropped, nil
+}
+
+// pop waits for the next packet; ok is false once the queue is closed
+// and drained
+func (q *commandQueue) pop() (*CommandPacket, bool) {
+	packet, ok := q.queue.Pop()
+	if !ok {
+		return nil, false
+	}
+	return packet.(*CommandPacket), true
+}
+
+func (q *commandQueue) close() {
+	q.queue.Close(false)
+}
+
+// forward feeds out until the queue is closed and drained, then
+// closes it. Once done is closed it no longer waits on a consumer:
+// packets that out has room for are still delivered, and the rest
+// are dropped
+func (q *commandQueue) forward(out chan<- *CommandPacket, done <-chan struct{}) {
+	defer close(out)
+	for {
+		packet, ok := q.pop()
+		if !ok {
+			return
+		}
+		select {
+		case out <- packet:
+			continue
+		default:
+		}
+		select {
+		case out <- packet:
+		case <-done:
+			return
+		}
+	}
+}
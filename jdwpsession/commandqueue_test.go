@@ -0,0 +1,107 @@
+package jdwpsession_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const queueLimit = 3
+
+// flood sends numbered command packets, none of which are read, and
+// then a command whose reply shows they have all been dispatched
+func flood(t *testing.T, session jdwpsession.Session, srv *jdwptest.Server, count int) error {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		if err := srv.SendCommand(&jdwpsession.CommandPacket{Commandset: 64, Command: 100, Data: []byte{byte(i)}}); err != nil {
+			t.Fatalf("SendCommand: %v", err)
+		}
+	}
+	_, err := sendCommand(t, session, vm.VersionCommand)
+	return err
+}
+
+// drain returns the numbers of the packets still queued
+func drain(t *testing.T, session jdwpsession.Session) []int {
+	t.Helper()
+	var numbers []int
+	for {
+		select {
+		case packet := <-session.JvmCommandPacketChannel():
+			numbers = append(numbers, int(packet.Data[0]))
+		case <-time.After(100 * time.Millisecond):
+			return numbers
+		}
+	}
+}
+
+func isRun(numbers []int, from int) bool {
+	for idx, number := range numbers {
+		if number != from+idx {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCommandQueueUnbounded(t *testing.T) {
+	const count = 200
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{})
+	}, jdwpsession.WithPacketQueueLength(1))
+	if err := flood(t, session, srv, count); err != nil {
+		t.Fatalf("Version with the consumer stalled: %v", err)
+	}
+	if numbers := drain(t, session); len(numbers) != count || !isRun(numbers, 0) {
+		t.Fatalf("got packets %v, want all %v in order", numbers, count)
+	}
+}
+
+func TestCommandQueueDropOldest(t *testing.T) {
+	const count = 20
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{})
+	}, jdwpsession.WithPacketQueueLength(0), jdwpsession.WithCommandQueueLimit(queueLimit, jdwpsession.OverflowDropOldest))
+	if err := flood(t, session, srv, count); err != nil {
+		t.Fatalf("Version with the consumer stalled: %v", err)
+	}
+	// the newest are kept, behind perhaps one the channel had already
+	// taken off the queue
+	numbers := drain(t, session)
+	if len(numbers) < queueLimit || len(numbers) > queueLimit+1 || !isRun(numbers[len(numbers)-queueLimit:], count-queueLimit) {
+		t.Fatalf("got packets %v, want the last %v", numbers, queueLimit)
+	}
+}
+
+func TestCommandQueueDropNewest(t *testing.T) {
+	const count = 20
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{})
+	}, jdwpsession.WithPacketQueueLength(0), jdwpsession.WithCommandQueueLimit(queueLimit, jdwpsession.OverflowDropNewest))
+	if err := flood(t, session, srv, count); err != nil {
+		t.Fatalf("Version with the consumer stalled: %v", err)
+	}
+	// the oldest are kept, and perhaps one that arrived once the
+	// channel had taken a packet off the queue
+	numbers := drain(t, session)
+	if len(numbers) < queueLimit || len(numbers) > queueLimit+1 || !isRun(numbers[:queueLimit], 0) {
+		t.Fatalf("got packets %v, want the first %v", numbers, queueLimit)
+	}
+}
+
+func TestCommandQueueFail(t *testing.T) {
+	session, srv := startSession(t, nil, jdwpsession.WithPacketQueueLength(0),
+		jdwpsession.WithCommandQueueLimit(queueLimit, jdwpsession.OverflowFail))
+	for i := 0; i < queueLimit+2; i++ {
+		// the session may have failed before the last are written
+		srv.SendCommand(&jdwpsession.CommandPacket{Commandset: 64, Command: 100, Data: []byte{byte(i)}})
+	}
+	waitDone(t, session)
+	if err := session.Err(); err == nil || !strings.Contains(err.Error(), "queue overflow") {
+		t.Fatalf("Err: got %v, want queue overflow", err)
+	}
+}
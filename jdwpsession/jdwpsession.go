@@ -1,19 +1,20 @@
 package jdwpsession
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
-)
 
-const defaultPacketQueueLength = 50
-const defaultReadDeadlineMillis = 2000
-const defaultWriteDeadlineMillis = 2000
+	"github.com/jquirke/jdwpgo/api/jdwp"
+)
 
 const headerBytes = 11
 const handshakebytes = "JDWP-Handshake"
@@ -24,27 +25,59 @@ const (
 	sessionHandshake
 	sessionOpen
 	sessionFailed
+	sessionStopping
+	sessionStopped
 )
 
+// disposeCommand is VirtualMachine.Dispose, sent by Stop
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_VirtualMachine_Dispose
+var disposeCommand = CommandPacket{Commandset: 1, Command: 6}
+
+// disposeTimeout bounds how long Stop waits for the VM to acknowledge
+// Dispose before closing the connection regardless
+const disposeTimeout = 2 * time.Second
+
+// ErrSessionClosed is returned for requests that cannot complete
+// because the session has been stopped or has failed
+var ErrSessionClosed = errors.New("session closed")
+
 // Session implements the low level JWDP session abstraction
 // it is thread safe and supports concurrent in flight
 // requests/responses
 type Session interface {
 	Start() error
 	Stop() error
+	// JvmCommandPacketChannel delivers command packets from the VM,
+	// such as events. It is closed once the session has shut down;
+	// packets still queued that it has no room for are then dropped
 	JvmCommandPacketChannel() <-chan *CommandPacket
+	// Logger returns the logger the session was configured with
+	Logger() *slog.Logger
 	SendCommand(*CommandPacket) <-chan *ReplyPacket
+	SendCommandContext(context.Context, *CommandPacket) (*ReplyPacket, error)
+	// Done is closed once the session has shut down, either by Stop or
+	// because of a connection error
+	Done() <-chan struct{}
+	// Err returns nil until Done is closed, then ErrSessionClosed if
+	// the session was stopped, or the error that brought it down
+	Err() error
 }
 
 type session struct {
+	options
 	conn              net.Conn
 	jvmCommandPackets chan *CommandPacket
+	commandQueue      *commandQueue
 	sessionMutex      sync.Mutex
 	// mutex protected
 	requestPending      map[uint32]*request
+	requestAbandoned    map[uint32]struct{}
 	requestPendingQueue chan *request
 	state               int32
 	sequence            uint32
+	err                 error
+	done                chan struct{}
+	shutdownOnce        sync.Once
 }
 
 type request struct {
@@ -80,8 +113,9 @@ type CommandPacket struct {
 }
 
 func (c *CommandPacket) String() string {
-	return fmt.Sprintf("{commandset=%v[TODO] command=%v[TODO] length=%v",
-		c.Commandset, c.Command, len(c.Data))
+	return fmt.Sprintf("{commandset=%v[%s] command=%v[%s] length=%v",
+		c.Commandset, jdwp.CommandsetName(c.Commandset),
+		c.Command, jdwp.CommandName(c.Commandset, c.Command), len(c.Data))
 }
 
 // ReplyPacket represents a reply packet
@@ -96,11 +130,15 @@ func (r *ReplyPacket) String() string {
 }
 
 // New creates a new JWDP session
-func New(conn net.Conn) Session {
+func New(conn net.Conn, opts ...Option) Session {
+	o := newOptions(opts)
 	return &session{
+		options:             o,
 		conn:                conn,
 		requestPending:      make(map[uint32]*request),
-		requestPendingQueue: make(chan *request, 10),
+		requestAbandoned:    make(map[uint32]struct{}),
+		requestPendingQueue: make(chan *request, o.requestQueueLength),
+		done:                make(chan struct{}),
 	}
 }
 
@@ -120,7 +158,9 @@ func (s *session) Start() error {
 		s.state = sessionFailed
 		return err
 	}
-	s.jvmCommandPackets = make(chan *CommandPacket, defaultPacketQueueLength)
+	s.jvmCommandPackets = make(chan *CommandPacket, s.packetQueueLength)
+	s.commandQueue = newCommandQueue(s.queueLimit, s.overflowPolicy)
+	go s.commandQueue.forward(s.jvmCommandPackets, s.done)
 	s.state = sessionOpen
 	go s.rxLoop()
 	go s.txLoop()
@@ -128,55 +168,142 @@ func (s *session) Start() error {
 }
 
 func (s *session) writeHandshakeFrame() error {
-	s.conn.SetWriteDeadline(time.Now().Add(defaultWriteDeadlineMillis * time.Millisecond))
+	s.conn.SetWriteDeadline(deadline(s.writeDeadline))
 	_, err := s.conn.Write([]byte(handshakebytes))
 	return err
 }
 
 func (s *session) readAndCheckHandshakeFrame() error {
-	s.conn.SetReadDeadline(time.Now().Add(defaultReadDeadlineMillis * time.Millisecond))
+	s.conn.SetReadDeadline(deadline(s.readDeadline))
+	if s.handshakeBanner > 0 {
+		return s.readHandshakeAfterBanner()
+	}
 	buf := make([]byte, len(handshakebytes))
-	_, err := io.ReadFull(s.conn, buf)
-	return err
+	n, err := io.ReadFull(s.conn, buf)
+	if err != nil {
+		return fmt.Errorf("reading handshake, got %q: %v", buf[:n], err)
+	}
+	if string(buf) != handshakebytes {
+		return fmt.Errorf("bad handshake: expected %q, got %q; is the peer a JDWP agent?",
+			handshakebytes, buf)
+	}
+	return nil
+}
+
+// readHandshakeAfterBanner reads a byte at a time until the handshake
+// is seen, so that nothing past it is consumed
+func (s *session) readHandshakeAfterBanner() error {
+	limit := s.handshakeBanner + len(handshakebytes)
+	buf := make([]byte, 0, limit)
+	b := make([]byte, 1)
+	for len(buf) < limit {
+		if _, err := io.ReadFull(s.conn, b); err != nil {
+			return fmt.Errorf("reading handshake, got %q: %v", quoteTail(buf), err)
+		}
+		buf = append(buf, b[0])
+		if bytes.HasSuffix(buf, []byte(handshakebytes)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no handshake within %v bytes, got %q; is the peer a JDWP agent?",
+		limit, quoteTail(buf))
+}
+
+// quoteTail trims received data for use in an error message
+func quoteTail(buf []byte) []byte {
+	const maxQuoted = 64
+	if len(buf) > maxQuoted {
+		return buf[len(buf)-maxQuoted:]
+	}
+	return buf
 }
 
 func (s *session) rxLoop() {
-	for atomic.LoadInt32(&s.state) == sessionOpen {
+	for {
 		err := s.dispatchInboundPacket()
 		if err != nil {
-			s.setErrorState(err)
-			break
+			s.shutdown(err)
+			return
 		}
 	}
+}
 
-	close(s.jvmCommandPackets)
-	for _, request := range s.requestPending {
-		close(request.replyCh)
+func (s *session) txLoop() {
+	for {
+		select {
+		case request := <-s.requestPendingQueue:
+			if !s.claimForWrite(request) {
+				continue
+			}
+			err := s.writePacket(request)
+			if err != nil {
+				s.shutdown(err)
+				return
+			}
+		case <-s.done:
+			return
+		}
 	}
 }
 
-func (s *session) setErrorState(err error) {
+// shutdown closes the connection, which stops the rxLoop, stops the
+// txLoop and fails every pending request. The first call wins: err is
+// what Err reports from then on
+func (s *session) shutdown(err error) {
+	s.shutdownOnce.Do(func() {
+		s.sessionMutex.Lock()
+		if s.state == sessionStopping {
+			s.state = sessionStopped
+		} else {
+			s.logger.Error("closing session due to error", "error", err)
+			s.state = sessionFailed
+		}
+		s.err = err
+		pending := s.requestPending
+		s.requestPending = make(map[uint32]*request)
+		close(s.done)
+		s.sessionMutex.Unlock()
+
+		// queued events are delivered while there is room for them,
+		// then the channel closes
+		s.commandQueue.close()
+
+		s.conn.Close()
+		// a request is only in the pending map until its reply is sent,
+		// so nothing else can be sending on these
+		for _, request := range pending {
+			close(request.replyCh)
+		}
+	})
+}
+
+// claimForWrite reports whether a queued request should still be
+// written; requests abandoned before transmission are dropped here
+func (s *session) claimForWrite(request *request) bool {
 	s.sessionMutex.Lock()
 	defer s.sessionMutex.Unlock()
-	fmt.Printf("closing session due to error: %v\n", err)
-	if s.state == sessionOpen {
-		s.state = sessionFailed
+	if _, ok := s.requestPending[request.id]; ok {
+		return true
 	}
+	delete(s.requestAbandoned, request.id)
+	return false
 }
 
-func (s *session) txLoop() {
-	// TODO need exit from here
-	for request := range s.requestPendingQueue {
-		err := s.writePacket(request)
-		if err != nil {
-			s.setErrorState(err)
-			break
-		}
+func (s *session) captureRecord(direction Direction, wrappedPacket *WrappedPacket) {
+	if err := s.capture.capture(direction, wrappedPacket); err != nil {
+		s.logger.Warn("failed to capture packet", "error", err)
 	}
 }
 
 func (s *session) writePacket(request *request) error {
-	s.conn.SetWriteDeadline(time.Now().Add(defaultWriteDeadlineMillis * time.Millisecond))
+	// captured before writing so it cannot be recorded after its reply
+	if s.capture != nil {
+		s.captureRecord(DirectionOutbound, &WrappedPacket{
+			id:            request.id,
+			commandPacket: request.commandPacket,
+		})
+	}
+	s.conn.SetWriteDeadline(deadline(s.writeDeadline))
 	var totalsize = 11 + (uint32)(len(request.commandPacket.Data))
 	err := binary.Write(s.conn, binary.BigEndian, totalsize)
 	if err != nil {
@@ -198,6 +325,9 @@ func (s *session) writePacket(request *request) error {
 	if err != nil {
 		return err
 	}
+	if len(request.commandPacket.Data) == 0 {
+		return nil
+	}
 	n, err := s.conn.Write(request.commandPacket.Data)
 	if err != nil {
 		return err
@@ -211,25 +341,32 @@ func (s *session) writePacket(request *request) error {
 
 func (s *session) dispatchInboundPacket() error {
 	wrappedPacket, err := s.readPacket()
-	s.sessionMutex.Lock()
-	defer s.sessionMutex.Unlock()
-
 	if err != nil {
 		return err
 	}
 	if wrappedPacket.isCommandPacket() {
-		s.jvmCommandPackets <- wrappedPacket.commandPacket
-	} else {
-		request, ok := s.requestPending[wrappedPacket.id]
-		if !ok {
-			fmt.Printf("warn: got unexpected reply for id: %v", wrappedPacket.id)
-		} else {
-			request.replyCh <- wrappedPacket.replyPacket
-			//close(request.replyCh) //TODO turn back on
+		dropped, err := s.commandQueue.push(wrappedPacket.commandPacket)
+		if dropped {
+			s.logger.Warn("JVM command queue full, dropped a packet", "policy", s.overflowPolicy)
 		}
+		return err
+	}
+
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	request, ok := s.requestPending[wrappedPacket.id]
+	if ok {
+		delete(s.requestPending, wrappedPacket.id)
+		request.replyCh <- wrappedPacket.replyPacket
+	} else if _, abandoned := s.requestAbandoned[wrappedPacket.id]; abandoned {
+		// late reply to a cancelled request
+		delete(s.requestAbandoned, wrappedPacket.id)
+	} else {
+		s.logger.Warn("got unexpected reply", "id", wrappedPacket.id)
 	}
 	return nil
 }
+
 func (s *session) readPacket() (*WrappedPacket, error) {
 	var wrappedPacket WrappedPacket
 	s.conn.SetReadDeadline(time.Time{})
@@ -238,10 +375,13 @@ func (s *session) readPacket() (*WrappedPacket, error) {
 	if err != nil {
 		return nil, err
 	}
-	s.conn.SetReadDeadline(time.Now().Add(defaultReadDeadlineMillis * time.Millisecond))
+	s.conn.SetReadDeadline(deadline(s.readDeadline))
 	if size < headerBytes {
 		return nil, fmt.Errorf("packet too small: %v", size)
 	}
+	if s.maxPacketSize > 0 && size > s.maxPacketSize {
+		return nil, fmt.Errorf("packet too large: %v > %v", size, s.maxPacketSize)
+	}
 	dataSize := size - headerBytes
 	err = binary.Read(s.conn, binary.BigEndian, &wrappedPacket.id)
 	if err != nil {
@@ -280,38 +420,135 @@ func (s *session) readPacket() (*WrappedPacket, error) {
 	if err != nil {
 		return nil, err
 	}
+	if s.capture != nil {
+		s.captureRecord(DirectionInbound, &wrappedPacket)
+	}
 	return &wrappedPacket, nil
 }
 
+// Stop asks the VM to dispose of the debugging connection, then
+// closes it. Dispose is best effort: the connection is closed even if
+// the VM does not reply in time, and every request still pending
+// fails with ErrSessionClosed
 func (s *session) Stop() error {
 	s.sessionMutex.Lock()
-	defer s.sessionMutex.Unlock()
-
-	if s.state == sessionOpen {
-		s.state = sessionClosed
-	} else {
-		return fmt.Errorf("session not open: %v", s.state)
+	if s.state != sessionOpen {
+		state := s.state
+		s.sessionMutex.Unlock()
+		return fmt.Errorf("session not open: %v", state)
 	}
+	s.state = sessionStopping
+	s.sessionMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), disposeTimeout)
+	defer cancel()
+	dispose := disposeCommand
+	s.SendCommandContext(ctx, &dispose)
+
+	s.shutdown(ErrSessionClosed)
 	return nil
 }
 
+func (s *session) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *session) Err() error {
+	select {
+	case <-s.done:
+	default:
+		return nil
+	}
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	return s.err
+}
+
+func (s *session) Logger() *slog.Logger {
+	return s.logger
+}
+
 func (s *session) JvmCommandPacketChannel() <-chan *CommandPacket {
 	return s.jvmCommandPackets
 }
 
+// SendCommand sends a command; the returned channel receives the
+// reply, or is closed without one if the session shuts down first
 func (s *session) SendCommand(commandPacket *CommandPacket) <-chan *ReplyPacket {
+	request, err := s.newPendingRequest(commandPacket)
+	if err != nil {
+		replyCh := make(chan *ReplyPacket)
+		close(replyCh)
+		return replyCh
+	}
+	// the transmission MUST occur after
+
+	select {
+	case s.requestPendingQueue <- request:
+	case <-s.done:
+	}
+
+	return request.replyCh
+}
+
+// SendCommandContext sends a command and waits for its reply. If ctx
+// is done first the request is abandoned: it is not transmitted if it
+// has not been already, and any late reply is silently discarded
+func (s *session) SendCommandContext(ctx context.Context, commandPacket *CommandPacket) (*ReplyPacket, error) {
+	request, err := s.newPendingRequest(commandPacket)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.requestPendingQueue <- request:
+	case <-s.done:
+		return nil, ErrSessionClosed
+	case <-ctx.Done():
+		s.abandonRequest(request, false)
+		return nil, ctx.Err()
+	}
+
+	select {
+	case reply, ok := <-request.replyCh:
+		if !ok {
+			return nil, ErrSessionClosed
+		}
+		return reply, nil
+	case <-ctx.Done():
+		s.abandonRequest(request, true)
+		return nil, ctx.Err()
+	}
+}
+
+func (s *session) newPendingRequest(commandPacket *CommandPacket) (*request, error) {
 	sendid := atomic.AddUint32(&s.sequence, 1)
-	request := request{
+	request := &request{
 		id:            sendid,
 		replyCh:       make(chan *ReplyPacket, 1),
 		commandPacket: commandPacket,
 	}
 	s.sessionMutex.Lock()
-	s.requestPending[sendid] = &request
-	s.sessionMutex.Unlock()
-	// the transmission MUST occur after
-
-	s.requestPendingQueue <- &request
+	defer s.sessionMutex.Unlock()
+	if s.state != sessionOpen && s.state != sessionStopping {
+		return nil, ErrSessionClosed
+	}
+	s.requestPending[sendid] = request
+	return request, nil
+}
 
-	return request.replyCh
+// abandonRequest removes a request from the pending map. If it was
+// queued for transmission its id is remembered so that the txLoop can
+// skip it, or the rxLoop can drop its reply without complaint
+func (s *session) abandonRequest(request *request, queued bool) {
+	s.sessionMutex.Lock()
+	defer s.sessionMutex.Unlock()
+	if _, ok := s.requestPending[request.id]; !ok {
+		// reply already dispatched
+		return
+	}
+	delete(s.requestPending, request.id)
+	if queued {
+		s.requestAbandoned[request.id] = struct{}{}
+	}
 }
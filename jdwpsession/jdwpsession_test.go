@@ -0,0 +1,385 @@
+package jdwpsession_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const testTimeout = 5 * time.Second
+
+// startSession starts srv, after configure has scripted it, and a
+// session on the other end of its pipe
+func startSession(t *testing.T, configure func(*jdwptest.Server), opts ...jdwpsession.Option) (jdwpsession.Session, *jdwptest.Server) {
+	t.Helper()
+	srv, conn := jdwptest.NewPipe()
+	if configure != nil {
+		configure(srv)
+	}
+	srv.Start()
+	session := jdwpsession.New(conn, opts...)
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		session.Stop()
+		srv.Close()
+	})
+	return session, srv
+}
+
+func commandPacket(cmd jdwp.Command) *jdwpsession.CommandPacket {
+	return &jdwpsession.CommandPacket{Commandset: cmd.Commandset, Command: cmd.Command}
+}
+
+func sendCommand(t *testing.T, session jdwpsession.Session, cmd jdwp.Command) (*jdwpsession.ReplyPacket, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	return session.SendCommandContext(ctx, commandPacket(cmd))
+}
+
+func waitDone(t *testing.T, session jdwpsession.Session) {
+	t.Helper()
+	select {
+	case <-session.Done():
+	case <-time.After(testTimeout):
+		t.Fatal("session did not shut down")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		handshake string
+		opts      []jdwpsession.Option
+		wantErr   string
+	}{
+		{
+			name:      "exact",
+			handshake: "JDWP-Handshake",
+		},
+		{
+			name:      "not a JDWP agent",
+			handshake: "SSH-2.0-OpenSSH_9.6\r\n",
+			wantErr:   "is the peer a JDWP agent?",
+		},
+		{
+			name:      "banner tolerated",
+			handshake: "Welcome to the tunnel\r\nJDWP-Handshake",
+			opts:      []jdwpsession.Option{jdwpsession.WithHandshakeBanner(64)},
+		},
+		{
+			name:      "banner rejected by default",
+			handshake: "Welcome to the tunnel\r\nJDWP-Handshake",
+			wantErr:   "bad handshake",
+		},
+		{
+			name:      "banner too long",
+			handshake: strings.Repeat("x", 100) + "JDWP-Handshake",
+			opts:      []jdwpsession.Option{jdwpsession.WithHandshakeBanner(16)},
+			wantErr:   "no handshake within 30 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, conn := jdwptest.NewPipe()
+			srv.Handshake = tt.handshake
+			srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{})
+			srv.Start()
+			defer srv.Close()
+			session := jdwpsession.New(conn, tt.opts...)
+			err := session.Start()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Start: got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer session.Stop()
+			// nothing past the handshake was consumed
+			if _, err := sendCommand(t, session, vm.VersionCommand); err != nil {
+				t.Fatalf("command after handshake: %v", err)
+			}
+		})
+	}
+}
+
+func TestReplyData(t *testing.T) {
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleData(vm.VersionCommand, []byte{1, 2, 3})
+	})
+	reply, err := sendCommand(t, session, vm.VersionCommand)
+	if err != nil {
+		t.Fatalf("SendCommandContext: %v", err)
+	}
+	if reply.Errorcode != 0 || !bytes.Equal(reply.Data, []byte{1, 2, 3}) {
+		t.Fatalf("got reply %v data % X", reply, reply.Data)
+	}
+	received := srv.Received()
+	last := received[len(received)-1]
+	if last.Commandset != vm.VersionCommand.Commandset || last.Command != vm.VersionCommand.Command {
+		t.Fatalf("server received %v", last)
+	}
+}
+
+func TestErrorReply(t *testing.T) {
+	session, _ := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleError(vm.VersionCommand, jdwp.ErrorVmDead)
+	})
+	reply, err := sendCommand(t, session, vm.VersionCommand)
+	if err != nil {
+		t.Fatalf("SendCommandContext: %v", err)
+	}
+	if reply.Errorcode != uint16(jdwp.ErrorVmDead) {
+		t.Fatalf("got error code %v, want %v", reply.Errorcode, jdwp.ErrorVmDead)
+	}
+	// unhandled commands get NOT_IMPLEMENTED from the fake VM
+	reply, err = sendCommand(t, session, vm.AllThreadsCommand)
+	if err != nil {
+		t.Fatalf("SendCommandContext: %v", err)
+	}
+	if reply.Errorcode != uint16(jdwp.ErrorNotImplemented) {
+		t.Fatalf("got error code %v, want %v", reply.Errorcode, jdwp.ErrorNotImplemented)
+	}
+}
+
+func TestFaultDelayLetsLaterRepliesOvertake(t *testing.T) {
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleData(vm.VersionCommand, []byte{1})
+		srv.HandleData(vm.AllThreadsCommand, []byte{2})
+		srv.InjectFault(vm.VersionCommand, jdwptest.Fault{Delay: 200 * time.Millisecond})
+	})
+	delayed := session.SendCommand(commandPacket(vm.VersionCommand))
+	prompt := session.SendCommand(commandPacket(vm.AllThreadsCommand))
+	select {
+	case reply := <-prompt:
+		if !bytes.Equal(reply.Data, []byte{2}) {
+			t.Fatalf("prompt reply: got data % X", reply.Data)
+		}
+	case <-delayed:
+		t.Fatal("delayed reply arrived first")
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for prompt reply")
+	}
+	select {
+	case reply := <-delayed:
+		if !bytes.Equal(reply.Data, []byte{1}) {
+			t.Fatalf("delayed reply: got data % X", reply.Data)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for delayed reply")
+	}
+	if err := srv.Err(); err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestFaultDropTimesOut(t *testing.T) {
+	session, _ := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleData(vm.VersionCommand, []byte{1})
+		srv.InjectFault(vm.VersionCommand, jdwptest.Fault{Drop: true})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := session.SendCommandContext(ctx, commandPacket(vm.VersionCommand))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	// the session is unaffected
+	reply, err := sendCommand(t, session, vm.VersionCommand)
+	if err != nil || !bytes.Equal(reply.Data, []byte{1}) {
+		t.Fatalf("after drop: got %v, %v", reply, err)
+	}
+}
+
+func TestFaultChunkSizeForcesShortReads(t *testing.T) {
+	data := bytes.Repeat([]byte{0xAB}, 100)
+	session, _ := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleData(vm.VersionCommand, data)
+		srv.InjectFault(vm.VersionCommand, jdwptest.Fault{ChunkSize: 3, ChunkDelay: time.Millisecond})
+	})
+	reply, err := sendCommand(t, session, vm.VersionCommand)
+	if err != nil {
+		t.Fatalf("SendCommandContext: %v", err)
+	}
+	if !bytes.Equal(reply.Data, data) {
+		t.Fatalf("got data % X", reply.Data)
+	}
+}
+
+func TestFaultsFailingTheSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   jdwptest.Fault
+		opts    []jdwpsession.Option
+		wantErr string
+	}{
+		{
+			name:    "bad size too small",
+			fault:   jdwptest.Fault{BadSize: 5},
+			wantErr: "packet too small: 5",
+		},
+		{
+			name:    "bad size too large",
+			fault:   jdwptest.Fault{BadSize: 1 << 20},
+			opts:    []jdwpsession.Option{jdwpsession.WithMaxPacketSize(1 << 16)},
+			wantErr: "packet too large",
+		},
+		{
+			name:  "truncate",
+			fault: jdwptest.Fault{Truncate: 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, _ := startSession(t, func(srv *jdwptest.Server) {
+				srv.HandleData(vm.VersionCommand, []byte{1, 2, 3, 4})
+				srv.InjectFault(vm.VersionCommand, tt.fault)
+			}, tt.opts...)
+			_, err := sendCommand(t, session, vm.VersionCommand)
+			if !errors.Is(err, jdwpsession.ErrSessionClosed) {
+				t.Fatalf("pending request: got %v, want ErrSessionClosed", err)
+			}
+			waitDone(t, session)
+			err = session.Err()
+			if err == nil || errors.Is(err, jdwpsession.ErrSessionClosed) {
+				t.Fatalf("Err: got %v, want the connection error", err)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Err: got %v, want error containing %q", err, tt.wantErr)
+			}
+			if _, err := sendCommand(t, session, vm.VersionCommand); !errors.Is(err, jdwpsession.ErrSessionClosed) {
+				t.Fatalf("after failure: got %v, want ErrSessionClosed", err)
+			}
+		})
+	}
+}
+
+func TestStopFailsPendingRequests(t *testing.T) {
+	session, srv := startSession(t, func(srv *jdwptest.Server) {
+		// never replied to
+		srv.Handle(vm.VersionCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+			return nil
+		})
+	})
+	replyCh := session.SendCommand(commandPacket(vm.VersionCommand))
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := sendCommand(t, session, vm.VersionCommand)
+		errCh <- err
+	}()
+	// wait until both commands have reached the VM
+	deadline := time.Now().Add(testTimeout)
+	for len(srv.Received()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := session.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	select {
+	case reply, ok := <-replyCh:
+		if ok {
+			t.Fatalf("SendCommand: got reply %v, want closed channel", reply)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("SendCommand channel not closed by Stop")
+	}
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, jdwpsession.ErrSessionClosed) {
+			t.Fatalf("SendCommandContext: got %v, want ErrSessionClosed", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("SendCommandContext not failed by Stop")
+	}
+	waitDone(t, session)
+	if err := session.Err(); !errors.Is(err, jdwpsession.ErrSessionClosed) {
+		t.Fatalf("Err: got %v, want ErrSessionClosed", err)
+	}
+	if err := session.Stop(); err == nil {
+		t.Fatal("second Stop: expected error")
+	}
+
+	// Stop disposed of the connection
+	received := srv.Received()
+	last := received[len(received)-1]
+	if last.Commandset != vm.DisposeCommand.Commandset || last.Command != vm.DisposeCommand.Command {
+		t.Fatalf("last command: got %v, want Dispose", last)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	session, _ := startSession(t, func(srv *jdwptest.Server) {
+		srv.HandleData(vm.VersionCommand, []byte{1})
+		srv.HandleData(vm.AllThreadsCommand, []byte{2})
+		srv.InjectFault(vm.VersionCommand, jdwptest.Fault{Delay: 100 * time.Millisecond})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := session.SendCommandContext(ctx, commandPacket(vm.VersionCommand)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("already cancelled: got %v, want context.Canceled", err)
+	}
+
+	// cancelled while waiting for a delayed reply, which arrives late
+	// and is discarded
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := session.SendCommandContext(ctx, commandPacket(vm.VersionCommand)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("in flight: got %v, want context.Canceled", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	reply, err := sendCommand(t, session, vm.AllThreadsCommand)
+	if err != nil || !bytes.Equal(reply.Data, []byte{2}) {
+		t.Fatalf("after cancellation: got %v, %v", reply, err)
+	}
+	select {
+	case <-session.Done():
+		t.Fatalf("session shut down: %v", session.Err())
+	default:
+	}
+}
+
+func TestUnreadCommandPacketsDroppedOnShutdown(t *testing.T) {
+	const numPackets = 5
+	session, srv := startSession(t, nil, jdwpsession.WithPacketQueueLength(1))
+	for i := 0; i < numPackets; i++ {
+		if err := srv.SendCommand(&jdwpsession.CommandPacket{Commandset: 64, Command: 100}); err != nil {
+			t.Fatalf("SendCommand: %v", err)
+		}
+	}
+	srv.Close()
+	waitDone(t, session)
+
+	// nothing was reading, so only what fitted in the channel is left
+	received := 0
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case _, ok := <-session.JvmCommandPacketChannel():
+			if !ok {
+				if received == 0 || received >= numPackets {
+					t.Fatalf("got %v packets after shutdown, want between 1 and %v", received, numPackets-1)
+				}
+				return
+			}
+			received++
+		case <-timeout:
+			t.Fatal("command packet channel not closed after shutdown")
+		}
+	}
+}
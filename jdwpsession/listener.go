@@ -0,0 +1,106 @@
+package jdwpsession
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Listener accepts connections from VMs launched with the JDWP agent
+// in client mode (server=n), handing back a started Session for each.
+//
+// The VM side of the handshake is the same whichever end dialed, so
+// each accepted connection is started exactly as a dialed one would
+// be. Handshakes run concurrently, so a slow or bogus peer does not
+// hold up other VMs attaching.
+type Listener struct {
+	listener net.Listener
+	opts     []Option
+	sessions chan Session
+	done     chan struct{}
+	once     sync.Once
+	mutex    sync.Mutex
+	err      error
+}
+
+// Listen listens on the given network address, for example
+// Listen("tcp", ":5005")
+func Listen(network, address string, opts ...Option) (*Listener, error) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(listener, opts...), nil
+}
+
+// NewListener accepts VM connections from an existing net.Listener;
+// the Listener takes ownership of it. opts apply to every Session
+func NewListener(listener net.Listener, opts ...Option) *Listener {
+	l := &Listener{
+		listener: listener,
+		opts:     opts,
+		sessions: make(chan Session),
+		done:     make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+// Addr returns the address the listener is accepting on
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Accept waits for the next VM to attach and complete the handshake
+func (l *Listener) Accept() (Session, error) {
+	select {
+	case session := <-l.sessions:
+		return session, nil
+	case <-l.done:
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		return nil, l.err
+	}
+}
+
+// Close stops accepting; sessions already handed out are unaffected
+func (l *Listener) Close() error {
+	l.shutdown(errors.New("listener closed"))
+	return l.listener.Close()
+}
+
+func (l *Listener) shutdown(err error) {
+	l.once.Do(func() {
+		l.mutex.Lock()
+		l.err = err
+		l.mutex.Unlock()
+		close(l.done)
+	})
+}
+
+func (l *Listener) acceptLoop() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			l.shutdown(fmt.Errorf("accept: %v", err))
+			return
+		}
+		go l.handshake(conn)
+	}
+}
+
+func (l *Listener) handshake(conn net.Conn) {
+	session := New(conn, l.opts...)
+	if err := session.Start(); err != nil {
+		session.Logger().Warn("handshake failed", "remote", conn.RemoteAddr(), "error", err)
+		conn.Close()
+		return
+	}
+	select {
+	case l.sessions <- session:
+	case <-l.done:
+		session.Stop()
+		conn.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package jdwpsession_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+func TestListenerAcceptsConcurrentVMs(t *testing.T) {
+	listener, err := jdwpsession.Listen("tcp", "127.0.0.1:0", jdwpsession.WithReadDeadline(testTimeout))
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	// a peer that never completes the handshake, and one that gets it
+	// wrong, attach first
+	silent, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer silent.Close()
+	bogus, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer bogus.Close()
+	if _, err := bogus.Write([]byte("JDWP-Handshook")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	vmNames := []string{"vm0", "vm1", "vm2", "vm3"}
+	for _, name := range vmNames {
+		go func(name string) {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Errorf("Dial: %v", err)
+				return
+			}
+			srv := jdwptest.NewConn(conn)
+			srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{VMName: basetypes.NewJDWPString(name)})
+			srv.Start()
+			<-srv.Done()
+		}(name)
+	}
+
+	seen := make(map[string]bool)
+	for range vmNames {
+		accepted := make(chan jdwpsession.Session, 1)
+		go func() {
+			session, err := listener.Accept()
+			if err != nil {
+				t.Errorf("Accept: %v", err)
+			}
+			accepted <- session
+		}()
+		var session jdwpsession.Session
+		select {
+		case session = <-accepted:
+		case <-time.After(testTimeout):
+			t.Fatal("timed out accepting VMs")
+		}
+		if session == nil {
+			t.FailNow()
+		}
+		reply, err := sendCommand(t, session, vm.VersionCommand)
+		if err != nil {
+			t.Fatalf("Version: %v", err)
+		}
+		var version vm.VersionReply
+		if err := basetypes.DefaultIDSizes().Unpack(reply.Data, &version); err != nil {
+			t.Fatalf("Unpack: %v", err)
+		}
+		seen[version.VMName.String()] = true
+		if err := session.Stop(); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+	}
+	for _, name := range vmNames {
+		if !seen[name] {
+			t.Errorf("no session for %v, got %v", name, seen)
+		}
+	}
+}
+
+func TestListenerClose(t *testing.T) {
+	listener, err := jdwpsession.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	listener.Close()
+	if _, err := listener.Accept(); err == nil {
+		t.Fatal("Accept after Close: expected error")
+	}
+}
@@ -0,0 +1,143 @@
+package jdwpsession
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const defaultPacketQueueLength = 50
+const defaultRequestQueueLength = 10
+const defaultReadDeadline = 2 * time.Second
+const defaultWriteDeadline = 2 * time.Second
+
+// defaultMaxPacketSize bounds the allocation made for a packet read
+// from the wire; the largest replies, such as ClassFile bytes or big
+// array regions, are comfortably below it
+const defaultMaxPacketSize = 64 << 20
+
+// Option configures a Session
+type Option func(*options)
+
+type options struct {
+	readDeadline       time.Duration
+	writeDeadline      time.Duration
+	packetQueueLength  int
+	requestQueueLength int
+	maxPacketSize      uint32
+	queueLimit         int
+	overflowPolicy     OverflowPolicy
+	handshakeBanner    int
+	logger             *slog.Logger
+	capture            *CaptureWriter
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		readDeadline:       defaultReadDeadline,
+		writeDeadline:      defaultWriteDeadline,
+		packetQueueLength:  defaultPacketQueueLength,
+		requestQueueLength: defaultRequestQueueLength,
+		maxPacketSize:      defaultMaxPacketSize,
+		logger:             slog.New(discardHandler{}),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithReadDeadline sets how long the VM has to finish sending a packet
+// once its length has arrived, and to complete the handshake. Zero
+// waits forever. The default is 2s, which slow links can exceed
+func WithReadDeadline(d time.Duration) Option {
+	return func(o *options) {
+		o.readDeadline = d
+	}
+}
+
+// WithWriteDeadline sets how long writing a packet or the handshake
+// may take. Zero waits forever. The default is 2s
+func WithWriteDeadline(d time.Duration) Option {
+	return func(o *options) {
+		o.writeDeadline = d
+	}
+}
+
+// WithPacketQueueLength sets the buffer of the channel returned by
+// JvmCommandPacketChannel. The default is 50
+func WithPacketQueueLength(length int) Option {
+	return func(o *options) {
+		o.packetQueueLength = length
+	}
+}
+
+// WithRequestQueueLength sets how many commands may wait to be written
+// before senders block. The default is 10
+func WithRequestQueueLength(length int) Option {
+	return func(o *options) {
+		o.requestQueueLength = length
+	}
+}
+
+// WithMaxPacketSize sets the largest packet accepted from the VM,
+// header included; a larger one fails the session. The default is 64MiB
+func WithMaxPacketSize(size uint32) Option {
+	return func(o *options) {
+		o.maxPacketSize = size
+	}
+}
+
+// WithLogger sets the logger for warnings and session failures. By
+// default nothing is logged
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithHandshakeBanner tolerates up to maxBytes of data arriving ahead
+// of the VM's "JDWP-Handshake" reply, such as the banner some proxies
+// and tunnels write when a connection is opened. Without it anything
+// other than an exact handshake fails Start
+func WithHandshakeBanner(maxBytes int) Option {
+	return func(o *options) {
+		o.handshakeBanner = maxBytes
+	}
+}
+
+// WithCommandQueueLimit bounds the queue of command packets from the
+// VM, chiefly events, waiting to be read from JvmCommandPacketChannel.
+// By default the queue is unbounded so that reading replies never
+// waits on the consumer of events; policy decides what happens when a
+// bounded queue is full
+func WithCommandQueueLimit(limit int, policy OverflowPolicy) Option {
+	return func(o *options) {
+		o.queueLimit = limit
+		o.overflowPolicy = policy
+	}
+}
+
+// WithCapture records every packet sent and received, apart from the
+// handshake, to capture; see capture.go for the format
+func WithCapture(capture *CaptureWriter) Option {
+	return func(o *options) {
+		o.capture = capture
+	}
+}
+
+// deadline converts a timeout to a deadline, zero meaning none
+func deadline(d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// discardHandler is an slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
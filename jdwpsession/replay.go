@@ -0,0 +1,124 @@
+package jdwpsession
+
+import (
+	"fmt"
+	"io"
+	"net"
+)
+
+// NewReplayConn returns a connection whose peer replays a capture in
+// place of a VM, for reproducing a session offline. Pass it to New as
+// if it were a dialed connection.
+//
+// The peer answers the handshake, then walks the capture in order. For
+// each outbound record it waits for the debugger to send a command with
+// the same commandset and command; for each inbound record it sends the
+// captured packet, with reply ids rewritten to those of the debugger's
+// matching commands. Timestamps are ignored. The connection is closed
+// at the end of the capture, or as soon as the debugger deviates from
+// it; the reason is available from the returned ReplayStatus.
+func NewReplayConn(r io.Reader) (net.Conn, *ReplayStatus, error) {
+	captureReader, err := NewCaptureReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	peerConn, debuggerConn := net.Pipe()
+	status := &ReplayStatus{done: make(chan struct{})}
+	go status.replay(peerConn, captureReader)
+	return debuggerConn, status, nil
+}
+
+// ReplayStatus reports the outcome of a replay
+type ReplayStatus struct {
+	done    chan struct{}
+	err     error
+	records int
+}
+
+// Done is closed once the replay has finished
+func (r *ReplayStatus) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns nil if the whole capture was replayed, otherwise why the
+// replay stopped. It must only be called after Done is closed
+func (r *ReplayStatus) Err() error {
+	return r.err
+}
+
+// Records returns the number of records replayed. It must only be
+// called after Done is closed
+func (r *ReplayStatus) Records() int {
+	return r.records
+}
+
+func (r *ReplayStatus) replay(conn net.Conn, captureReader *CaptureReader) {
+	defer close(r.done)
+	defer conn.Close()
+
+	handshake := make([]byte, len(handshakebytes))
+	if _, err := io.ReadFull(conn, handshake); err != nil {
+		r.err = err
+		return
+	}
+	if _, err := conn.Write([]byte(handshakebytes)); err != nil {
+		r.err = err
+		return
+	}
+
+	// captured command id -> id the debugger used this time
+	idMap := make(map[uint32]uint32)
+	for {
+		record, err := captureReader.ReadRecord()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			r.err = err
+			return
+		}
+		if record.Direction == DirectionOutbound {
+			err = r.expectCommand(conn, record, idMap)
+		} else {
+			err = r.sendPacket(conn, record, idMap)
+		}
+		if err != nil {
+			r.err = fmt.Errorf("record %v: %v", r.records, err)
+			return
+		}
+		r.records++
+	}
+}
+
+func (r *ReplayStatus) expectCommand(conn net.Conn, record *CaptureRecord, idMap map[uint32]uint32) error {
+	if record.CommandPacket == nil {
+		return fmt.Errorf("replaying outbound replies is not supported: %v", record)
+	}
+	wrappedPacket, err := decodePacket(conn)
+	if err != nil {
+		return err
+	}
+	if !wrappedPacket.isCommandPacket() {
+		return fmt.Errorf("debugger sent a reply, expected %v", record.CommandPacket)
+	}
+	if wrappedPacket.commandPacket.Commandset != record.CommandPacket.Commandset ||
+		wrappedPacket.commandPacket.Command != record.CommandPacket.Command {
+		return fmt.Errorf("debugger sent %v, expected %v", wrappedPacket.commandPacket, record.CommandPacket)
+	}
+	idMap[record.ID] = wrappedPacket.id
+	return nil
+}
+
+func (r *ReplayStatus) sendPacket(conn net.Conn, record *CaptureRecord, idMap map[uint32]uint32) error {
+	id := record.ID
+	if record.ReplyPacket != nil {
+		liveID, ok := idMap[record.ID]
+		if !ok {
+			return fmt.Errorf("captured reply id %v has no matching command", record.ID)
+		}
+		delete(idMap, record.ID)
+		id = liveID
+	}
+	_, err := conn.Write(encodePacket(id, record.Flags, record.CommandPacket, record.ReplyPacket))
+	return err
+}
@@ -0,0 +1,457 @@
+// Package jdwptest provides a scriptable fake JVM, speaking the VM
+// side of JDWP, for exercising jdwpsession and debuggercore without a
+// real JVM.
+//
+// A Server is created unstarted so that its replies, events and faults
+// can be scripted, then Start is called before the client handshakes:
+//
+//	srv, conn := jdwptest.NewPipe()
+//	srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{...})
+//	srv.Start()
+//	session := jdwpsession.New(conn)
+package jdwptest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/event"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const headerBytes = 11
+const handshakebytes = "JDWP-Handshake"
+const flagsReplyPacket = 0x80
+
+// Handler produces the reply to a command. Returning nil sends no reply
+type Handler func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket
+
+// Fault describes a misbehaviour to apply to a single reply
+type Fault struct {
+	// Delay holds the reply back; other replies may overtake it
+	Delay time.Duration
+	// Wait, if set, holds the reply back until it is closed, after any
+	// Delay; other replies may overtake it
+	Wait <-chan struct{}
+	// Drop discards the reply entirely
+	Drop bool
+	// BadSize, if non zero, replaces the length in the packet header
+	BadSize uint32
+	// ChunkSize, if non zero, writes the packet in chunks of this many
+	// bytes, pausing ChunkDelay between them, to force short reads
+	ChunkSize  int
+	ChunkDelay time.Duration
+	// Truncate, if non zero, writes only this many bytes of the packet
+	// and then closes the connection
+	Truncate int
+}
+
+type commandKey struct {
+	commandset byte
+	command    byte
+}
+
+// Server is a fake JVM serving one JDWP connection
+type Server struct {
+	// Handshake is the handshake the server expects and sends back
+	Handshake string
+	// IDSizes are reported by the default IDSizes handler and used to
+	// pack struct replies and events
+	IDSizes basetypes.IDSizes
+
+	listener net.Listener
+	conn     net.Conn
+
+	mutex    sync.Mutex
+	handlers map[commandKey]Handler
+	faults   map[commandKey][]Fault
+	received []*jdwpsession.CommandPacket
+	err      error
+	eventID  uint32
+
+	writeMutex sync.Mutex
+	started    bool
+	done       chan struct{}
+}
+
+func newServer() *Server {
+	s := &Server{
+		Handshake: handshakebytes,
+		IDSizes:   basetypes.DefaultIDSizes(),
+		handlers:  make(map[commandKey]Handler),
+		faults:    make(map[commandKey][]Fault),
+		done:      make(chan struct{}),
+	}
+	s.Handle(vm.IDSizesCommand, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		return s.StructReply(&vm.IDSizesReply{
+			FieldIDSize:         int32(s.IDSizes.FieldIDSize),
+			MethodIDSize:        int32(s.IDSizes.MethodIDSize),
+			ObjectIDSize:        int32(s.IDSizes.ObjectIDSize),
+			ReferenceTypeIDSize: int32(s.IDSizes.ReferenceTypeIDSize),
+			FrameIDSize:         int32(s.IDSizes.FrameIDSize),
+		})
+	})
+	// acknowledged so that Session.Stop does not wait out its timeout
+	s.HandleData(vm.DisposeCommand, nil)
+	return s
+}
+
+// NewPipe creates an unstarted server connected over net.Pipe, and
+// returns the debugger end of the pipe
+func NewPipe() (*Server, net.Conn) {
+	s := newServer()
+	serverConn, clientConn := net.Pipe()
+	s.conn = serverConn
+	return s, clientConn
+}
+
+// NewConn creates an unstarted server on an established connection,
+// such as one dialed to a debugger listening for VMs in client mode
+func NewConn(conn net.Conn) *Server {
+	s := newServer()
+	s.conn = conn
+	return s
+}
+
+// NewListener creates an unstarted server listening on a loopback
+// port; it accepts a single connection once started
+func NewListener() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := newServer()
+	s.listener = listener
+	return s, nil
+}
+
+// Addr returns the address a listening server accepts on
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Handle sets the handler for a command
+func (s *Server) Handle(cmd jdwp.Command, handler Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[commandKey{cmd.Commandset, cmd.Command}] = handler
+}
+
+// HandleData replies to a command with fixed reply data
+func (s *Server) HandleData(cmd jdwp.Command, data []byte) {
+	s.Handle(cmd, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		return &jdwpsession.ReplyPacket{Data: data}
+	})
+}
+
+// HandleStruct replies to a command with a reply struct, packed using
+// the server's ID sizes at the time of the command
+func (s *Server) HandleStruct(cmd jdwp.Command, replyStruct interface{}) {
+	s.Handle(cmd, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		return s.StructReply(replyStruct)
+	})
+}
+
+// HandleError replies to a command with an error code
+func (s *Server) HandleError(cmd jdwp.Command, errorCode jdwp.ErrorCode) {
+	s.Handle(cmd, func(*jdwpsession.CommandPacket) *jdwpsession.ReplyPacket {
+		return &jdwpsession.ReplyPacket{Errorcode: uint16(errorCode)}
+	})
+}
+
+// StructReply packs a reply struct using the server's ID sizes, for
+// handlers whose reply depends on the command
+func (s *Server) StructReply(replyStruct interface{}) *jdwpsession.ReplyPacket {
+	data, err := s.IDSizes.Pack(replyStruct)
+	if err != nil {
+		s.setErr(fmt.Errorf("packing reply %T: %v", replyStruct, err))
+		return &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorInternal)}
+	}
+	return &jdwpsession.ReplyPacket{Data: data}
+}
+
+// UnpackCommand unpacks a command's data into a command data struct
+// using the server's ID sizes
+func (s *Server) UnpackCommand(commandPacket *jdwpsession.CommandPacket, commandData interface{}) error {
+	return s.IDSizes.Unpack(commandPacket.Data, commandData)
+}
+
+// InjectFault queues a fault to apply to the next reply to a command;
+// faults queued for the same command are applied in order
+func (s *Server) InjectFault(cmd jdwp.Command, fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key := commandKey{cmd.Commandset, cmd.Command}
+	s.faults[key] = append(s.faults[key], fault)
+}
+
+// Received returns the commands received so far, in arrival order
+func (s *Server) Received() []*jdwpsession.CommandPacket {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	received := make([]*jdwpsession.CommandPacket, len(s.received))
+	copy(received, s.received)
+	return received
+}
+
+// Err returns the first error the server hit, if any
+func (s *Server) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+func (s *Server) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Done is closed when the server stops serving
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Start begins serving in the background
+func (s *Server) Start() {
+	s.mutex.Lock()
+	if s.started {
+		s.mutex.Unlock()
+		panic("jdwptest: server already started")
+	}
+	s.started = true
+	s.mutex.Unlock()
+	go s.serve()
+}
+
+// Close shuts the server down, closing the connection
+func (s *Server) Close() error {
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	// not under writeMutex, which a write blocked on the debugger holds
+	s.mutex.Lock()
+	conn := s.conn
+	s.mutex.Unlock()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) serve() {
+	defer close(s.done)
+	if s.listener != nil {
+		conn, err := s.listener.Accept()
+		s.listener.Close()
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+		s.mutex.Lock()
+		s.conn = conn
+		s.mutex.Unlock()
+	}
+	if err := s.handshake(); err != nil {
+		s.setErr(err)
+		s.conn.Close()
+		return
+	}
+	for {
+		commandPacket, id, err := s.readCommand()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, net.ErrClosed) {
+				s.setErr(err)
+			}
+			return
+		}
+		s.dispatch(commandPacket, id)
+	}
+}
+
+func (s *Server) handshake() error {
+	buf := make([]byte, len(handshakebytes))
+	if _, err := io.ReadFull(s.conn, buf); err != nil {
+		return err
+	}
+	if string(buf) != handshakebytes {
+		return fmt.Errorf("bad handshake from debugger: %q", buf)
+	}
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	_, err := s.conn.Write([]byte(s.Handshake))
+	return err
+}
+
+func (s *Server) readCommand() (*jdwpsession.CommandPacket, uint32, error) {
+	header := make([]byte, headerBytes)
+	if _, err := io.ReadFull(s.conn, header); err != nil {
+		return nil, 0, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	id := binary.BigEndian.Uint32(header[4:8])
+	if header[8]&flagsReplyPacket != 0 {
+		return nil, 0, fmt.Errorf("debugger sent a reply packet, id %v", id)
+	}
+	if size < headerBytes {
+		return nil, 0, fmt.Errorf("packet too small: %v", size)
+	}
+	commandPacket := &jdwpsession.CommandPacket{
+		Commandset: header[9],
+		Command:    header[10],
+		Data:       make([]byte, size-headerBytes),
+	}
+	if _, err := io.ReadFull(s.conn, commandPacket.Data); err != nil {
+		return nil, 0, err
+	}
+	return commandPacket, id, nil
+}
+
+func (s *Server) dispatch(commandPacket *jdwpsession.CommandPacket, id uint32) {
+	key := commandKey{commandPacket.Commandset, commandPacket.Command}
+	s.mutex.Lock()
+	s.received = append(s.received, commandPacket)
+	handler, ok := s.handlers[key]
+	var fault Fault
+	if faults := s.faults[key]; len(faults) > 0 {
+		fault = faults[0]
+		s.faults[key] = faults[1:]
+	}
+	s.mutex.Unlock()
+
+	var replyPacket *jdwpsession.ReplyPacket
+	if ok {
+		replyPacket = handler(commandPacket)
+	} else {
+		replyPacket = &jdwpsession.ReplyPacket{Errorcode: uint16(jdwp.ErrorNotImplemented)}
+	}
+	if replyPacket == nil || fault.Drop {
+		return
+	}
+
+	packet := encodePacket(id, flagsReplyPacket, replyHeader(replyPacket), replyPacket.Data)
+	if fault.Delay > 0 || fault.Wait != nil {
+		go func() {
+			time.Sleep(fault.Delay)
+			if fault.Wait != nil {
+				<-fault.Wait
+			}
+			s.writeFaulty(packet, fault)
+		}()
+		return
+	}
+	s.writeFaulty(packet, fault)
+}
+
+func replyHeader(replyPacket *jdwpsession.ReplyPacket) []byte {
+	header := make([]byte, 2)
+	binary.BigEndian.PutUint16(header, replyPacket.Errorcode)
+	return header
+}
+
+func encodePacket(id uint32, flags byte, header []byte, data []byte) []byte {
+	packet := make([]byte, 9, headerBytes+len(data))
+	binary.BigEndian.PutUint32(packet[0:4], uint32(9+len(header)+len(data)))
+	binary.BigEndian.PutUint32(packet[4:8], id)
+	packet[8] = flags
+	packet = append(packet, header...)
+	return append(packet, data...)
+}
+
+func (s *Server) writeFaulty(packet []byte, fault Fault) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	if fault.BadSize != 0 {
+		binary.BigEndian.PutUint32(packet[0:4], fault.BadSize)
+	}
+	truncated := false
+	if fault.Truncate > 0 && fault.Truncate < len(packet) {
+		packet = packet[:fault.Truncate]
+		truncated = true
+	}
+	chunkSize := fault.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(packet)
+	}
+	for len(packet) > 0 {
+		n := chunkSize
+		if n > len(packet) {
+			n = len(packet)
+		}
+		if _, err := s.conn.Write(packet[:n]); err != nil {
+			// a reply still in flight when the server is closed
+			if !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, net.ErrClosed) {
+				s.setErr(err)
+			}
+			return
+		}
+		packet = packet[n:]
+		if len(packet) > 0 && fault.ChunkDelay > 0 {
+			time.Sleep(fault.ChunkDelay)
+		}
+	}
+	if truncated {
+		s.conn.Close()
+	}
+}
+
+// SendCommand sends an unsolicited command packet to the debugger
+func (s *Server) SendCommand(commandPacket *jdwpsession.CommandPacket) error {
+	s.mutex.Lock()
+	s.eventID++
+	id := s.eventID
+	conn := s.conn
+	s.mutex.Unlock()
+
+	packet := encodePacket(id, 0, []byte{commandPacket.Commandset, commandPacket.Command}, commandPacket.Data)
+	if conn == nil {
+		return errors.New("jdwptest: not connected")
+	}
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	_, err := conn.Write(packet)
+	return err
+}
+
+// SendEvents sends a composite event command holding the given events
+func (s *Server) SendEvents(suspendPolicy event.SuspendPolicy, events ...event.Event) error {
+	data, err := EncodeComposite(s.IDSizes, suspendPolicy, events...)
+	if err != nil {
+		return err
+	}
+	return s.SendCommand(&jdwpsession.CommandPacket{
+		Commandset: event.CompositeCommand.Commandset,
+		Command:    event.CompositeCommand.Command,
+		Data:       data,
+	})
+}
+
+// EncodeComposite encodes events as composite command data
+func EncodeComposite(idSizes basetypes.IDSizes, suspendPolicy event.SuspendPolicy, events ...event.Event) ([]byte, error) {
+	data := make([]byte, 5)
+	data[0] = byte(suspendPolicy)
+	binary.BigEndian.PutUint32(data[1:], uint32(len(events)))
+	for _, ev := range events {
+		eventData, err := idSizes.Pack(ev)
+		if err != nil {
+			return nil, fmt.Errorf("packing %v event: %v", ev.Kind(), err)
+		}
+		data = append(data, byte(ev.Kind()))
+		data = append(data, eventData...)
+	}
+	return data, nil
+}
@@ -0,0 +1,171 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jquirke/jdwpgo/jdwptest"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+const testTimeout = 5 * time.Second
+
+// fakeJavaEnv makes the test binary act as java; see fakeJava
+const fakeJavaEnv = "JDWPGO_FAKE_JAVA"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeJavaEnv) != "" {
+		os.Exit(fakeJava(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeJava stands in for java, serving JDWP from a jdwptest server as
+// the agent arg asks. The main class picks what else it does:
+// "Crash" fails before the agent starts, and anything else stays up
+// after the debugger disconnects so that only a kill stops it
+func fakeJava(args []string) int {
+	agentArg, main := args[0], args[len(args)-1]
+	if main == "Crash" {
+		fmt.Fprintln(os.Stderr, "Error: Could not find or load main class Crash")
+		return 1
+	}
+	options := make(map[string]string)
+	for _, option := range strings.Split(strings.TrimPrefix(agentArg, "-agentlib:jdwp="), ",") {
+		if kv := strings.SplitN(option, "=", 2); len(kv) == 2 {
+			options[kv[0]] = kv[1]
+		}
+	}
+	var srv *jdwptest.Server
+	if options["server"] == "y" {
+		var err error
+		srv, err = jdwptest.NewListener()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		_, port, _ := net.SplitHostPort(srv.Addr().String())
+		fmt.Printf("Listening for transport dt_socket at address: %s\n", port)
+	} else {
+		conn, err := net.Dial("tcp", options["address"])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		srv = jdwptest.NewConn(conn)
+	}
+	srv.HandleStruct(vm.VersionCommand, &vm.VersionReply{VMName: basetypes.NewJDWPString(main)})
+	srv.Start()
+	<-srv.Done()
+	time.Sleep(time.Hour)
+	return 0
+}
+
+// fakeJavaScript writes a java executable that runs fakeJava
+func fakeJavaScript(t *testing.T) string {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Executable: %v", err)
+	}
+	java := filepath.Join(t.TempDir(), "java")
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 exec '%s' \"$@\"\n", fakeJavaEnv, executable)
+	if err := os.WriteFile(java, []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return java
+}
+
+// syncBuffer collects process output written from another goroutine
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buffer.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buffer.String()
+}
+
+func TestLaunch(t *testing.T) {
+	java := fakeJavaScript(t)
+	tests := []struct {
+		name string
+		mode Mode
+	}{
+		{"server", ModeServer},
+		{"client", ModeClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &syncBuffer{}
+			process, err := Launch(context.Background(), Config{
+				Java:         java,
+				Main:         "com.example.Main",
+				Mode:         tt.mode,
+				Stdout:       stdout,
+				StartTimeout: testTimeout,
+			})
+			if err != nil {
+				t.Fatalf("Launch: %v", err)
+			}
+			version, err := process.DebuggerCore.VMCommands().Version()
+			if err != nil {
+				t.Fatalf("Version: %v", err)
+			}
+			if got := version.VMName.String(); got != "com.example.Main" {
+				t.Fatalf("VMName: got %q, want the fake's main class", got)
+			}
+			if tt.mode == ModeServer && !strings.Contains(stdout.String(), "Listening for transport dt_socket") {
+				t.Fatalf("stdout: got %q, want the agent line", stdout.String())
+			}
+
+			// the fake outlives the debugger unless it is killed
+			if err := process.Session.Stop(); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			select {
+			case <-process.Exited():
+			case <-time.After(testTimeout):
+				t.Fatal("process not killed on Stop")
+			}
+			if err := process.Wait(); err == nil || !strings.Contains(err.Error(), "killed") {
+				t.Fatalf("Wait: got %v, want killed", err)
+			}
+		})
+	}
+}
+
+func TestLaunchErrorIncludesStderr(t *testing.T) {
+	for _, mode := range []Mode{ModeServer, ModeClient} {
+		_, err := Launch(context.Background(), Config{
+			Java:         fakeJavaScript(t),
+			Main:         "Crash",
+			Mode:         mode,
+			StartTimeout: testTimeout,
+		})
+		if err == nil {
+			t.Fatal("Launch: expected error")
+		}
+		if !strings.Contains(err.Error(), "java exited before") ||
+			!strings.Contains(err.Error(), "stderr: Error: Could not find or load main class Crash") {
+			t.Fatalf("Launch: got %v, want exit error with stderr", err)
+		}
+	}
+}
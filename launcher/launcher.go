@@ -0,0 +1,332 @@
+// Package launcher starts a java process with the JDWP agent loaded
+// and attaches a debugger to it
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jquirke/jdwpgo/debuggercore"
+	"github.com/jquirke/jdwpgo/jdwpsession"
+)
+
+const defaultStartTimeout = 30 * time.Second
+
+// loopbackAddress is handed to the agent in server mode; port 0 lets it
+// pick a free port, which it reports in the line listeningRegexp matches
+const loopbackAddress = "127.0.0.1:0"
+
+// listeningRegexp matches the line the agent prints to stdout once it
+// is accepting connections in server mode
+var listeningRegexp = regexp.MustCompile(`Listening for transport \S+ at address: (\S+)`)
+
+// Mode selects which side of the JDWP connection listens
+type Mode int
+
+const (
+	// ModeServer - the VM listens on a loopback port of its choosing
+	// (server=y) and the debugger dials the port the agent reports
+	ModeServer Mode = iota
+	// ModeClient - the debugger listens and the VM dials it (server=n)
+	ModeClient
+)
+
+// Config describes the java process to launch
+type Config struct {
+	// Java is the java executable; by default $JAVA_HOME/bin/java if
+	// JAVA_HOME is set, otherwise java from the PATH
+	Java string
+	// JVMArgs are placed before the main class, for example "-cp", "app.jar"
+	JVMArgs []string
+	// Main is the main class, or "-jar" followed by a jar in Args
+	Main string
+	// Args are passed to the main class
+	Args []string
+	// NoSuspend lets the VM run before the debugger has attached
+	NoSuspend bool
+	Mode      Mode
+	// Dir and Env are passed to exec.Cmd
+	Dir string
+	Env []string
+	// Stdout and Stderr, if set, receive the process output
+	Stdout io.Writer
+	Stderr io.Writer
+	// StartTimeout bounds how long to wait for the agent; 30s by default
+	StartTimeout time.Duration
+	// SessionOptions configure the JDWP session
+	SessionOptions []jdwpsession.Option
+}
+
+// Process is a launched java process with a debugger attached
+type Process struct {
+	Cmd *exec.Cmd
+	// Session stops the process when it is stopped
+	Session      jdwpsession.Session
+	DebuggerCore debuggercore.DebuggerCore
+
+	exited  chan struct{}
+	waitErr error
+}
+
+// Exited is closed when the process exits
+func (p *Process) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// Wait waits for the process to exit and returns its exit error
+func (p *Process) Wait() error {
+	<-p.exited
+	return p.waitErr
+}
+
+// Kill kills the process and waits for it to exit
+func (p *Process) Kill() error {
+	select {
+	case <-p.exited:
+		return nil
+	default:
+	}
+	if err := p.Cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-p.exited
+	return nil
+}
+
+// AgentArg returns the -agentlib:jdwp argument for a mode and address
+func AgentArg(mode Mode, address string, suspend bool) string {
+	server := "y"
+	if mode == ModeClient {
+		server = "n"
+	}
+	suspendFlag := "y"
+	if !suspend {
+		suspendFlag = "n"
+	}
+	return fmt.Sprintf("-agentlib:jdwp=transport=dt_socket,server=%s,suspend=%s,address=%s",
+		server, suspendFlag, address)
+}
+
+// Launch starts the java process and attaches to it. The process is
+// killed if attaching fails, or when the returned Session is stopped
+func Launch(ctx context.Context, config Config) (*Process, error) {
+	if config.Main == "" {
+		return nil, errors.New("no main class")
+	}
+	startTimeout := config.StartTimeout
+	if startTimeout <= 0 {
+		startTimeout = defaultStartTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	var listener *jdwpsession.Listener
+	address := loopbackAddress
+	var err error
+	if config.Mode == ModeClient {
+		listener, err = jdwpsession.Listen("tcp", "127.0.0.1:0", config.SessionOptions...)
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+		address = listener.Addr().String()
+	}
+
+	args := append([]string{AgentArg(config.Mode, address, !config.NoSuspend)}, config.JVMArgs...)
+	args = append(args, config.Main)
+	args = append(args, config.Args...)
+	cmd := exec.Command(javaExecutable(config.Java), args...)
+	cmd.Dir = config.Dir
+	cmd.Env = config.Env
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	process := &Process{
+		Cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+	listening := make(chan string, 1)
+	stderrTail := &tailBuffer{}
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		scanOutput(stdout, config.Stdout, func(line string) {
+			if match := listeningRegexp.FindStringSubmatch(line); match != nil {
+				select {
+				case listening <- match[1]:
+				default:
+				}
+			}
+		})
+	}()
+	go func() {
+		defer output.Done()
+		scanOutput(stderr, config.Stderr, stderrTail.add)
+	}()
+	go func() {
+		// the pipes must be drained before Wait closes them
+		output.Wait()
+		process.waitErr = cmd.Wait()
+		close(process.exited)
+	}()
+
+	var session jdwpsession.Session
+	if config.Mode == ModeClient {
+		session, err = acceptVM(ctx, listener, process)
+	} else {
+		session, err = dialVM(ctx, listening, process, config.SessionOptions)
+	}
+	if err == nil {
+		process.Session = &processSession{Session: session, process: process}
+		process.DebuggerCore, err = debuggercore.NewFromJWDPSessionContext(ctx, process.Session)
+		if err != nil {
+			process.Session.Stop()
+		}
+	}
+	if err != nil {
+		process.Kill()
+		if tail := stderrTail.String(); tail != "" {
+			return nil, fmt.Errorf("%v; stderr: %s", err, tail)
+		}
+		return nil, err
+	}
+	return process, nil
+}
+
+func javaExecutable(java string) string {
+	if java != "" {
+		return java
+	}
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		return filepath.Join(javaHome, "bin", "java")
+	}
+	return "java"
+}
+
+// dialAddress turns the address the agent reports it is listening on
+// into one to dial. Recent agents report only the port; older ones
+// report host:port, where the host may be a wildcard
+func dialAddress(reported string) string {
+	host, port, err := net.SplitHostPort(reported)
+	if err != nil {
+		host, port = "", reported
+	}
+	if ip := net.ParseIP(host); host == "" || host == "*" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func dialVM(ctx context.Context, listening <-chan string, process *Process, opts []jdwpsession.Option) (jdwpsession.Session, error) {
+	var address string
+	select {
+	case reported := <-listening:
+		address = dialAddress(reported)
+	case <-process.exited:
+		return nil, fmt.Errorf("java exited before the agent was listening: %v", process.waitErr)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for the agent to listen: %v", ctx.Err())
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	session := jdwpsession.New(conn, opts...)
+	if err := session.Start(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func acceptVM(ctx context.Context, listener *jdwpsession.Listener, process *Process) (jdwpsession.Session, error) {
+	type accepted struct {
+		session jdwpsession.Session
+		err     error
+	}
+	acceptCh := make(chan accepted, 1)
+	go func() {
+		session, err := listener.Accept()
+		acceptCh <- accepted{session, err}
+	}()
+	select {
+	case result := <-acceptCh:
+		return result.session, result.err
+	case <-process.exited:
+		return nil, fmt.Errorf("java exited before the agent attached: %v", process.waitErr)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for the agent to attach: %v", ctx.Err())
+	}
+}
+
+func scanOutput(r io.Reader, w io.Writer, onLine func(string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		onLine(line)
+		if w != nil {
+			fmt.Fprintln(w, line)
+		}
+	}
+	// keep draining if the scanner gave up on an overlong line
+	io.Copy(io.Discard, r)
+}
+
+// processSession kills the process when the session is stopped
+type processSession struct {
+	jdwpsession.Session
+	process *Process
+}
+
+func (p *processSession) Stop() error {
+	err := p.Session.Stop()
+	if killErr := p.process.Kill(); err == nil {
+		err = killErr
+	}
+	return err
+}
+
+const tailLines = 20
+
+// tailBuffer keeps the last few lines written to it
+type tailBuffer struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (t *tailBuffer) add(line string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > tailLines {
+		t.lines = t.lines[len(t.lines)-tailLines:]
+	}
+}
+
+func (t *tailBuffer) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return strings.Join(t.lines, "\n")
+}
@@ -0,0 +1,29 @@
+package launcher
+
+import "testing"
+
+func TestDialAddress(t *testing.T) {
+	tests := []struct {
+		reported string
+		want     string
+	}{
+		{"45678", "127.0.0.1:45678"},
+		{"127.0.0.1:45678", "127.0.0.1:45678"},
+		{"localhost:45678", "localhost:45678"},
+		{"*:45678", "127.0.0.1:45678"},
+		{"0.0.0.0:45678", "127.0.0.1:45678"},
+		{"[::1]:45678", "[::1]:45678"},
+	}
+	for _, tt := range tests {
+		if got := dialAddress(tt.reported); got != tt.want {
+			t.Errorf("dialAddress(%q): got %q, want %q", tt.reported, got, tt.want)
+		}
+	}
+}
+
+func TestAgentArgLetsServerPickPort(t *testing.T) {
+	want := "-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=127.0.0.1:0"
+	if got := AgentArg(ModeServer, loopbackAddress, true); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
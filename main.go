@@ -26,7 +26,11 @@ func main() {
 		return
 	}
 
-	debuggercore := debuggercore.NewFromJWDPSession(s)
+	debuggercore, err := debuggercore.NewFromJWDPSession(s)
+	if err != nil {
+		fmt.Printf("error debuggercore: %v\n", err)
+		return
+	}
 
 	version, err := debuggercore.VMCommands().Version()
 
@@ -1,6 +1,9 @@
 package basetypes
 
-import "fmt"
+import (
+	"encoding/binary"
+	"fmt"
+)
 
 // JDWPString represents string in JWDP wire format
 type JDWPString struct {
@@ -12,6 +15,14 @@ func (j *JDWPString) String() string {
 	return (string)(j.ByteString)
 }
 
+// NewJDWPString returns s in JWDP wire format
+func NewJDWPString(s string) JDWPString {
+	return JDWPString{
+		Length:     uint32(len(s)),
+		ByteString: []byte(s),
+	}
+}
+
 // EmptyJWDPString returns the empty string
 func EmptyJWDPString() JDWPString {
 	return JDWPString{
@@ -20,8 +31,12 @@ func EmptyJWDPString() JDWPString {
 	}
 }
 
-// TODO we need to extend the serialiser to allow these sizes
-// to be changed at runtime (IDSizes command)
+// The ID types below are variable width on the wire; their widths
+// are negotiated with the IDSizes command. They implement the restruct
+// Sizer/Packer/Unpacker interfaces and take their width from the
+// byte order passed to Pack/Unpack, which IDSizes.Pack and
+// IDSizes.Unpack set up as an idSizedOrder. Outside of those they are
+// 8 bytes wide.
 
 // JWDPObjectID represents objectID
 type JWDPObjectID struct {
@@ -32,6 +47,21 @@ func (j *JWDPObjectID) String() string {
 	return fmt.Sprintf("0x%X", j.ObjectID)
 }
 
+// SizeOf implements restruct.Sizer
+func (j JWDPObjectID) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPObjectID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packID(buf, order, idKindObject, j.ObjectID)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPObjectID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return unpackID(buf, order, idKindObject, &j.ObjectID)
+}
+
 // JWDPFrameID represents frameID
 type JWDPFrameID struct {
 	FrameID uint64
@@ -41,6 +71,21 @@ func (j *JWDPFrameID) String() string {
 	return fmt.Sprintf("0x%X", j.FrameID)
 }
 
+// SizeOf implements restruct.Sizer
+func (j JWDPFrameID) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPFrameID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packID(buf, order, idKindFrame, j.FrameID)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPFrameID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return unpackID(buf, order, idKindFrame, &j.FrameID)
+}
+
 // JWDPFieldID represents fieldID
 type JWDPFieldID struct {
 	FieldID uint64
@@ -50,6 +95,21 @@ func (j *JWDPFieldID) String() string {
 	return fmt.Sprintf("0x%X", j.FieldID)
 }
 
+// SizeOf implements restruct.Sizer
+func (j JWDPFieldID) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPFieldID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packID(buf, order, idKindField, j.FieldID)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPFieldID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return unpackID(buf, order, idKindField, &j.FieldID)
+}
+
 // JWDPRefTypeID represents refTypeID
 type JWDPRefTypeID struct {
 	RefTypeID uint64
@@ -59,6 +119,21 @@ func (j *JWDPRefTypeID) String() string {
 	return fmt.Sprintf("0x%X", j.RefTypeID)
 }
 
+// SizeOf implements restruct.Sizer
+func (j JWDPRefTypeID) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPRefTypeID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packID(buf, order, idKindReferenceType, j.RefTypeID)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPRefTypeID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return unpackID(buf, order, idKindReferenceType, &j.RefTypeID)
+}
+
 // JWDPMethodID respresents methodID
 type JWDPMethodID struct {
 	MethodID uint64
@@ -68,6 +143,21 @@ func (j *JWDPMethodID) String() string {
 	return fmt.Sprintf("0x%X", j.MethodID)
 }
 
+// SizeOf implements restruct.Sizer
+func (j JWDPMethodID) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPMethodID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packID(buf, order, idKindMethod, j.MethodID)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPMethodID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return unpackID(buf, order, idKindMethod, &j.MethodID)
+}
+
 // JWDPTypeTag represents type tag
 type JWDPTypeTag byte
 
@@ -0,0 +1,209 @@
+package basetypes
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/restruct.v1"
+)
+
+// maxIDSize is the widest ID supported, and is the size reported
+// to restruct when it sizes a buffer for packing
+const maxIDSize = 8
+
+type idKind int
+
+const (
+	idKindObject idKind = iota
+	idKindReferenceType
+	idKindMethod
+	idKindField
+	idKindFrame
+)
+
+// IDSizes holds the size in bytes of each of the variable sized ID
+// types, as reported by the VM in reply to the IDSizes command
+type IDSizes struct {
+	FieldIDSize         int
+	MethodIDSize        int
+	ObjectIDSize        int
+	ReferenceTypeIDSize int
+	FrameIDSize         int
+}
+
+// DefaultIDSizes returns 8 byte sizes for every ID type, which is what
+// HotSpot reports and what is used before the VM has been asked
+func DefaultIDSizes() IDSizes {
+	return IDSizes{
+		FieldIDSize:         maxIDSize,
+		MethodIDSize:        maxIDSize,
+		ObjectIDSize:        maxIDSize,
+		ReferenceTypeIDSize: maxIDSize,
+		FrameIDSize:         maxIDSize,
+	}
+}
+
+// Validate checks that every size is one the serialiser can handle
+func (i IDSizes) Validate() error {
+	sizes := []struct {
+		name string
+		size int
+	}{
+		{"FieldIDSize", i.FieldIDSize},
+		{"MethodIDSize", i.MethodIDSize},
+		{"ObjectIDSize", i.ObjectIDSize},
+		{"ReferenceTypeIDSize", i.ReferenceTypeIDSize},
+		{"FrameIDSize", i.FrameIDSize},
+	}
+	for _, s := range sizes {
+		if s.size < 1 || s.size > maxIDSize {
+			return fmt.Errorf("unsupported %s: %v", s.name, s.size)
+		}
+	}
+	return nil
+}
+
+func (i IDSizes) size(kind idKind) int {
+	switch kind {
+	case idKindObject:
+		return i.ObjectIDSize
+	case idKindReferenceType:
+		return i.ReferenceTypeIDSize
+	case idKindMethod:
+		return i.MethodIDSize
+	case idKindField:
+		return i.FieldIDSize
+	case idKindFrame:
+		return i.FrameIDSize
+	default:
+		return maxIDSize
+	}
+}
+
+// IDSizedPacker is implemented by data that cannot be described as a
+// single restruct struct, such as lists of heterogeneous records
+type IDSizedPacker interface {
+	PackIDSized(IDSizes) ([]byte, error)
+}
+
+// Pack serialises v to JDWP wire format using these ID sizes
+func (i IDSizes) Pack(v interface{}) ([]byte, error) {
+	if packer, ok := v.(IDSizedPacker); ok {
+		return packer.PackIDSized(i)
+	}
+	order := &idSizedOrder{ByteOrder: binary.BigEndian, sizes: i}
+	data, err := restruct.Pack(order, v)
+	if err != nil {
+		return nil, err
+	}
+	// restruct sized the buffer assuming maxIDSize for every ID
+	return data[:len(data)-order.slack], nil
+}
+
+// Unpack deserialises v from JDWP wire format using these ID sizes
+func (i IDSizes) Unpack(data []byte, v interface{}) error {
+	order := &idSizedOrder{ByteOrder: binary.BigEndian, sizes: i}
+	return restruct.Unpack(data, order, v)
+}
+
+// UnpackPrefix deserialises v, which must be a pointer to a struct,
+// from the front of data and returns the bytes left over. It is used
+// to walk packets holding a sequence of variable length records
+func (i IDSizes) UnpackPrefix(data []byte, v interface{}) ([]byte, error) {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected pointer to struct, got %T", v)
+	}
+	wrapperType := reflect.StructOf([]reflect.StructField{
+		{Name: "Value", Type: ptr.Elem().Type()},
+		{Name: "Rest", Type: reflect.TypeOf(remainder{})},
+	})
+	wrapper := reflect.New(wrapperType)
+	if err := i.Unpack(data, wrapper.Interface()); err != nil {
+		return nil, err
+	}
+	ptr.Elem().Set(wrapper.Elem().Field(0))
+	return wrapper.Elem().Field(1).Interface().(remainder).buf, nil
+}
+
+// remainder captures whatever is left of the buffer when it is unpacked
+type remainder struct {
+	buf []byte
+}
+
+func (r remainder) SizeOf() int {
+	return 0
+}
+
+func (r *remainder) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	r.buf = buf
+	return buf[len(buf):], nil
+}
+
+// idSizedOrder is the byte order handed to restruct; it carries the
+// ID sizes through to the ID Packers and Unpackers, and keeps count of
+// the buffer space left unused by IDs narrower than maxIDSize
+type idSizedOrder struct {
+	binary.ByteOrder
+	sizes IDSizes
+	slack int
+}
+
+func idSizeFromOrder(order binary.ByteOrder, kind idKind) int {
+	sizedOrder, ok := order.(*idSizedOrder)
+	if !ok {
+		return maxIDSize
+	}
+	return sizedOrder.sizes.size(kind)
+}
+
+func packID(buf []byte, order binary.ByteOrder, kind idKind, id uint64) ([]byte, error) {
+	size := idSizeFromOrder(order, kind)
+	if size < maxIDSize && id>>(8*uint(size)) != 0 {
+		return nil, fmt.Errorf("ID 0x%X does not fit in %v bytes", id, size)
+	}
+	buf, err := packBits(buf, size, id)
+	if err != nil {
+		return nil, err
+	}
+	addSlack(order, maxIDSize-size)
+	return buf, nil
+}
+
+func unpackID(buf []byte, order binary.ByteOrder, kind idKind, id *uint64) ([]byte, error) {
+	size := idSizeFromOrder(order, kind)
+	return unpackBits(buf, size, id)
+}
+
+// addSlack records buffer space that a Packer reserved via SizeOf
+// but did not use, so that IDSizes.Pack can trim it
+func addSlack(order binary.ByteOrder, slack int) {
+	if sizedOrder, ok := order.(*idSizedOrder); ok {
+		sizedOrder.slack += slack
+	}
+}
+
+// packBits writes the low size bytes of bits big endian
+func packBits(buf []byte, size int, bits uint64) ([]byte, error) {
+	if len(buf) < size {
+		return nil, fmt.Errorf("buffer too small: %v < %v", len(buf), size)
+	}
+	for idx := size - 1; idx >= 0; idx-- {
+		buf[idx] = byte(bits)
+		bits >>= 8
+	}
+	return buf[size:], nil
+}
+
+// unpackBits reads size bytes big endian
+func unpackBits(buf []byte, size int, bits *uint64) ([]byte, error) {
+	if len(buf) < size {
+		return nil, fmt.Errorf("buffer too small: %v < %v", len(buf), size)
+	}
+	*bits = 0
+	for idx := 0; idx < size; idx++ {
+		*bits = *bits<<8 | uint64(buf[idx])
+	}
+	return buf[size:], nil
+}
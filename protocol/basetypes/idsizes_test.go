@@ -0,0 +1,199 @@
+package basetypes
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mixedIDs struct {
+	Before   int32
+	Object   JWDPObjectID
+	RefType  JWDPRefTypeID
+	Method   JWDPMethodID
+	Field    JWDPFieldID
+	Frame    JWDPFrameID
+	Name     JDWPString
+	NumIDs   int32
+	ObjectID []JWDPObjectID `struct:"sizefrom=NumIDs"`
+	After    byte
+}
+
+func sampleMixedIDs() mixedIDs {
+	return mixedIDs{
+		Before:   0x01020304,
+		Object:   JWDPObjectID{ObjectID: 0x11},
+		RefType:  JWDPRefTypeID{RefTypeID: 0x22},
+		Method:   JWDPMethodID{MethodID: 0x33},
+		Field:    JWDPFieldID{FieldID: 0x44},
+		Frame:    JWDPFrameID{FrameID: 0x55},
+		Name:     NewJDWPString("ab"),
+		NumIDs:   2,
+		ObjectID: []JWDPObjectID{{ObjectID: 0x66}, {ObjectID: 0x77}},
+		After:    0xEE,
+	}
+}
+
+func TestIDSizesPackUnpack(t *testing.T) {
+	tests := []struct {
+		name    string
+		idSizes IDSizes
+		want    []byte
+	}{
+		{
+			name:    "all 8 bytes",
+			idSizes: DefaultIDSizes(),
+			want: concat(
+				[]byte{1, 2, 3, 4},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x11},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x22},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x33},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x44},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x55},
+				[]byte{0, 0, 0, 2, 'a', 'b'},
+				[]byte{0, 0, 0, 2},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x66},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x77},
+				[]byte{0xEE},
+			),
+		},
+		{
+			name: "all 4 bytes",
+			idSizes: IDSizes{
+				FieldIDSize:         4,
+				MethodIDSize:        4,
+				ObjectIDSize:        4,
+				ReferenceTypeIDSize: 4,
+				FrameIDSize:         4,
+			},
+			want: concat(
+				[]byte{1, 2, 3, 4},
+				[]byte{0, 0, 0, 0x11},
+				[]byte{0, 0, 0, 0x22},
+				[]byte{0, 0, 0, 0x33},
+				[]byte{0, 0, 0, 0x44},
+				[]byte{0, 0, 0, 0x55},
+				[]byte{0, 0, 0, 2, 'a', 'b'},
+				[]byte{0, 0, 0, 2},
+				[]byte{0, 0, 0, 0x66},
+				[]byte{0, 0, 0, 0x77},
+				[]byte{0xEE},
+			),
+		},
+		{
+			name: "mixed",
+			idSizes: IDSizes{
+				FieldIDSize:         2,
+				MethodIDSize:        8,
+				ObjectIDSize:        4,
+				ReferenceTypeIDSize: 8,
+				FrameIDSize:         1,
+			},
+			want: concat(
+				[]byte{1, 2, 3, 4},
+				[]byte{0, 0, 0, 0x11},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x22},
+				[]byte{0, 0, 0, 0, 0, 0, 0, 0x33},
+				[]byte{0, 0x44},
+				[]byte{0x55},
+				[]byte{0, 0, 0, 2, 'a', 'b'},
+				[]byte{0, 0, 0, 2},
+				[]byte{0, 0, 0, 0x66},
+				[]byte{0, 0, 0, 0x77},
+				[]byte{0xEE},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := sampleMixedIDs()
+			got, err := tt.idSizes.Pack(&in)
+			if err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Pack:\n got % X\nwant % X", got, tt.want)
+			}
+			var out mixedIDs
+			if err := tt.idSizes.Unpack(got, &out); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Fatalf("Unpack: got %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestIDSizesPackIDDoesNotFit(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	idSizes.ObjectIDSize = 4
+	in := struct {
+		Object JWDPObjectID
+	}{JWDPObjectID{ObjectID: 0x100000000}}
+	_, err := idSizes.Pack(&in)
+	if err == nil || !strings.Contains(err.Error(), "does not fit in 4 bytes") {
+		t.Fatalf("Pack: got %v, want does not fit error", err)
+	}
+
+	in.Object.ObjectID = 0xFFFFFFFF
+	got, err := idSizes.Pack(&in)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if want := []byte{0xFF, 0xFF, 0xFF, 0xFF}; !bytes.Equal(got, want) {
+		t.Fatalf("Pack: got % X, want % X", got, want)
+	}
+}
+
+func TestIDSizesUnpackShortBuffer(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	idSizes.ReferenceTypeIDSize = 4
+	var out struct {
+		RefType JWDPRefTypeID
+	}
+	if err := idSizes.Unpack([]byte{0, 0, 1}, &out); err == nil {
+		t.Fatal("Unpack: expected error for short buffer")
+	}
+}
+
+func TestIDSizesUnpackPrefix(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	idSizes.ObjectIDSize = 4
+	data := []byte{0, 0, 0, 7, 0xAA, 0xBB}
+	var out struct {
+		Object JWDPObjectID
+	}
+	rest, err := idSizes.UnpackPrefix(data, &out)
+	if err != nil {
+		t.Fatalf("UnpackPrefix: %v", err)
+	}
+	if out.Object.ObjectID != 7 {
+		t.Fatalf("UnpackPrefix: got ObjectID %v, want 7", out.Object.ObjectID)
+	}
+	if !bytes.Equal(rest, []byte{0xAA, 0xBB}) {
+		t.Fatalf("UnpackPrefix: got rest % X", rest)
+	}
+}
+
+func TestIDSizesValidate(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	if err := idSizes.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, size := range []int{0, 9} {
+		idSizes.FrameIDSize = size
+		if err := idSizes.Validate(); err == nil {
+			t.Fatalf("Validate: expected error for FrameIDSize %v", size)
+		}
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var all []byte
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	return all
+}
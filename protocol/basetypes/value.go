@@ -0,0 +1,459 @@
+package basetypes
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// JWDPTag represents the tag of a value
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Tag
+type JWDPTag byte
+
+const (
+	// JWDPTagArray - array object
+	JWDPTagArray JWDPTag = '['
+	// JWDPTagByte - byte value
+	JWDPTagByte JWDPTag = 'B'
+	// JWDPTagChar - char value
+	JWDPTagChar JWDPTag = 'C'
+	// JWDPTagObject - object
+	JWDPTagObject JWDPTag = 'L'
+	// JWDPTagFloat - float value
+	JWDPTagFloat JWDPTag = 'F'
+	// JWDPTagDouble - double value
+	JWDPTagDouble JWDPTag = 'D'
+	// JWDPTagInt - int value
+	JWDPTagInt JWDPTag = 'I'
+	// JWDPTagLong - long value
+	JWDPTagLong JWDPTag = 'J'
+	// JWDPTagShort - short value
+	JWDPTagShort JWDPTag = 'S'
+	// JWDPTagVoid - void value
+	JWDPTagVoid JWDPTag = 'V'
+	// JWDPTagBoolean - boolean value
+	JWDPTagBoolean JWDPTag = 'Z'
+	// JWDPTagString - String object
+	JWDPTagString JWDPTag = 's'
+	// JWDPTagThread - Thread object
+	JWDPTagThread JWDPTag = 't'
+	// JWDPTagThreadGroup - ThreadGroup object
+	JWDPTagThreadGroup JWDPTag = 'g'
+	// JWDPTagClassLoader - ClassLoader object
+	JWDPTagClassLoader JWDPTag = 'l'
+	// JWDPTagClassObject - class object object
+	JWDPTagClassObject JWDPTag = 'c'
+)
+
+// IsObject reports whether values with this tag are objectIDs
+func (j JWDPTag) IsObject() bool {
+	switch j {
+	case JWDPTagArray, JWDPTagObject, JWDPTagString, JWDPTagThread,
+		JWDPTagThreadGroup, JWDPTagClassLoader, JWDPTagClassObject:
+		return true
+	default:
+		return false
+	}
+}
+
+// primitiveSize returns the wire size of a primitive value with
+// this tag, or -1 if the tag is not a primitive
+func (j JWDPTag) primitiveSize() int {
+	switch j {
+	case JWDPTagByte, JWDPTagBoolean:
+		return 1
+	case JWDPTagChar, JWDPTagShort:
+		return 2
+	case JWDPTagFloat, JWDPTagInt:
+		return 4
+	case JWDPTagDouble, JWDPTagLong:
+		return 8
+	case JWDPTagVoid:
+		return 0
+	default:
+		return -1
+	}
+}
+
+func (j JWDPTag) String() string {
+	return string(rune(j))
+}
+
+// TagForSignature returns the tag for values of a type given its JNI
+// signature. Object types other than arrays map to JWDPTagObject, as
+// the more specific object tags cannot be told from a signature
+func TagForSignature(signature string) (JWDPTag, error) {
+	if signature == "" {
+		return 0, fmt.Errorf("empty signature")
+	}
+	tag := JWDPTag(signature[0])
+	if tag == JWDPTagArray || tag == JWDPTagObject || tag.primitiveSize() >= 0 {
+		return tag, nil
+	}
+	return 0, fmt.Errorf("bad signature: %q", signature)
+}
+
+// JWDPValue represents a tagged value
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Value
+type JWDPValue struct {
+	Tag JWDPTag
+	// Bits holds the raw bits of a primitive, or the objectID for
+	// object tags
+	Bits uint64
+}
+
+func (j JWDPValue) String() string {
+	switch {
+	case j.Tag == JWDPTagVoid:
+		return j.Tag.String()
+	case j.Tag.IsObject():
+		return fmt.Sprintf("%v:0x%X", j.Tag, j.Bits)
+	case j.Tag.primitiveSize() < 0:
+		return fmt.Sprintf("%v:0x%X", byte(j.Tag), j.Bits)
+	default:
+		return fmt.Sprintf("%v:%v", j.Tag, j.Interface())
+	}
+}
+
+// SizeOf implements restruct.Sizer
+func (j JWDPValue) SizeOf() int {
+	return 1 + maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPValue) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buf) < 1 {
+		return nil, fmt.Errorf("buffer too small for value tag")
+	}
+	buf[0] = byte(j.Tag)
+	return packUntaggedValue(buf[1:], order, j.Tag, j.Bits)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPValue) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buf) < 1 {
+		return nil, fmt.Errorf("buffer too small for value tag")
+	}
+	j.Tag = JWDPTag(buf[0])
+	return unpackUntaggedValue(buf[1:], order, j.Tag, &j.Bits)
+}
+
+// JWDPUntaggedValue represents an untagged-value; the tag is not on
+// the wire, so it must be set from context, such as a field signature,
+// before packing or unpacking
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Value
+type JWDPUntaggedValue JWDPValue
+
+func (j JWDPUntaggedValue) String() string {
+	return JWDPValue(j).String()
+}
+
+// SizeOf implements restruct.Sizer
+func (j JWDPUntaggedValue) SizeOf() int {
+	return maxIDSize
+}
+
+// Pack implements restruct.Packer
+func (j JWDPUntaggedValue) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return packUntaggedValue(buf, order, j.Tag, j.Bits)
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPUntaggedValue) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if j.Tag == 0 {
+		return nil, fmt.Errorf("untagged value needs its tag set before unpacking")
+	}
+	return unpackUntaggedValue(buf, order, j.Tag, &j.Bits)
+}
+
+func packUntaggedValue(buf []byte, order binary.ByteOrder, tag JWDPTag, bits uint64) ([]byte, error) {
+	if tag.IsObject() {
+		return packID(buf, order, idKindObject, bits)
+	}
+	size := tag.primitiveSize()
+	if size < 0 {
+		return nil, fmt.Errorf("unknown value tag: %v", byte(tag))
+	}
+	buf, err := packBits(buf, size, bits)
+	if err != nil {
+		return nil, err
+	}
+	addSlack(order, maxIDSize-size)
+	return buf, nil
+}
+
+func unpackUntaggedValue(buf []byte, order binary.ByteOrder, tag JWDPTag, bits *uint64) ([]byte, error) {
+	if tag.IsObject() {
+		return unpackID(buf, order, idKindObject, bits)
+	}
+	size := tag.primitiveSize()
+	if size < 0 {
+		return nil, fmt.Errorf("unknown value tag: %v", byte(tag))
+	}
+	return unpackBits(buf, size, bits)
+}
+
+// JWDPTaggedObjectID represents tagged-objectID
+type JWDPTaggedObjectID struct {
+	Tag      JWDPTag
+	ObjectID JWDPObjectID
+}
+
+func (j *JWDPTaggedObjectID) String() string {
+	return fmt.Sprintf("%v:%s", j.Tag, j.ObjectID.String())
+}
+
+// JWDPArrayRegion represents an arrayregion: primitive elements are
+// untagged-values, object elements are tagged since each may be of a
+// different object kind
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ArrayReference_GetValues
+type JWDPArrayRegion struct {
+	Tag    JWDPTag
+	Values []JWDPValue
+}
+
+// SizeOf implements restruct.Sizer
+func (j JWDPArrayRegion) SizeOf() int {
+	return 1 + 4 + len(j.Values)*(1+maxIDSize)
+}
+
+// Pack implements restruct.Packer
+func (j JWDPArrayRegion) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buf) < 5 {
+		return nil, fmt.Errorf("buffer too small for array region")
+	}
+	buf[0] = byte(j.Tag)
+	order.PutUint32(buf[1:], uint32(len(j.Values)))
+	buf = buf[5:]
+	var err error
+	for _, value := range j.Values {
+		if j.Tag.IsObject() {
+			buf, err = value.Pack(buf, order)
+		} else {
+			if value.Tag != j.Tag {
+				return nil, fmt.Errorf("%v value in %v array region", value.Tag, j.Tag)
+			}
+			buf, err = packUntaggedValue(buf, order, j.Tag, value.Bits)
+			addSlack(order, 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// Unpack implements restruct.Unpacker
+func (j *JWDPArrayRegion) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buf) < 5 {
+		return nil, fmt.Errorf("buffer too small for array region")
+	}
+	j.Tag = JWDPTag(buf[0])
+	length := int32(order.Uint32(buf[1:]))
+	buf = buf[5:]
+	if length < 0 || int(length) > len(buf) {
+		return nil, fmt.Errorf("bad array region length: %v", length)
+	}
+	j.Values = make([]JWDPValue, length)
+	var err error
+	for idx := range j.Values {
+		if j.Tag.IsObject() {
+			buf, err = j.Values[idx].Unpack(buf, order)
+		} else {
+			j.Values[idx].Tag = j.Tag
+			buf, err = unpackUntaggedValue(buf, order, j.Tag, &j.Values[idx].Bits)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// BooleanValue returns a boolean value
+func BooleanValue(b bool) JWDPValue {
+	if b {
+		return JWDPValue{Tag: JWDPTagBoolean, Bits: 1}
+	}
+	return JWDPValue{Tag: JWDPTagBoolean}
+}
+
+// ByteValue returns a byte value
+func ByteValue(b int8) JWDPValue {
+	return JWDPValue{Tag: JWDPTagByte, Bits: uint64(uint8(b))}
+}
+
+// CharValue returns a char value
+func CharValue(c uint16) JWDPValue {
+	return JWDPValue{Tag: JWDPTagChar, Bits: uint64(c)}
+}
+
+// ShortValue returns a short value
+func ShortValue(s int16) JWDPValue {
+	return JWDPValue{Tag: JWDPTagShort, Bits: uint64(uint16(s))}
+}
+
+// IntValue returns an int value
+func IntValue(i int32) JWDPValue {
+	return JWDPValue{Tag: JWDPTagInt, Bits: uint64(uint32(i))}
+}
+
+// LongValue returns a long value
+func LongValue(l int64) JWDPValue {
+	return JWDPValue{Tag: JWDPTagLong, Bits: uint64(l)}
+}
+
+// FloatValue returns a float value
+func FloatValue(f float32) JWDPValue {
+	return JWDPValue{Tag: JWDPTagFloat, Bits: uint64(math.Float32bits(f))}
+}
+
+// DoubleValue returns a double value
+func DoubleValue(d float64) JWDPValue {
+	return JWDPValue{Tag: JWDPTagDouble, Bits: math.Float64bits(d)}
+}
+
+// VoidValue returns the void value
+func VoidValue() JWDPValue {
+	return JWDPValue{Tag: JWDPTagVoid}
+}
+
+// ObjectValue returns an object value; tag must be an object tag
+func ObjectValue(tag JWDPTag, objectID JWDPObjectID) JWDPValue {
+	return JWDPValue{Tag: tag, Bits: objectID.ObjectID}
+}
+
+// NullValue returns a null object reference
+func NullValue() JWDPValue {
+	return JWDPValue{Tag: JWDPTagObject}
+}
+
+// NewValue converts a native Go value to the JDWP value of the
+// corresponding Java type: bool, int8, uint16 (char), int16, int32,
+// int64, float32, float64, JWDPObjectID, JWDPTaggedObjectID or nil
+// (a null object). Other integer types are not accepted, so that the
+// Java type is never guessed
+func NewValue(v interface{}) (JWDPValue, error) {
+	switch v := v.(type) {
+	case nil:
+		return NullValue(), nil
+	case JWDPValue:
+		return v, nil
+	case bool:
+		return BooleanValue(v), nil
+	case int8:
+		return ByteValue(v), nil
+	case uint16:
+		return CharValue(v), nil
+	case int16:
+		return ShortValue(v), nil
+	case int32:
+		return IntValue(v), nil
+	case int64:
+		return LongValue(v), nil
+	case float32:
+		return FloatValue(v), nil
+	case float64:
+		return DoubleValue(v), nil
+	case JWDPObjectID:
+		return ObjectValue(JWDPTagObject, v), nil
+	case JWDPTaggedObjectID:
+		return ObjectValue(v.Tag, v.ObjectID), nil
+	default:
+		return JWDPValue{}, fmt.Errorf("no JDWP value for %T", v)
+	}
+}
+
+// Interface converts the value to the native Go type NewValue accepts
+// for its tag; object values become a JWDPTaggedObjectID and void
+// becomes nil
+func (j JWDPValue) Interface() interface{} {
+	switch j.Tag {
+	case JWDPTagBoolean:
+		return j.Bits != 0
+	case JWDPTagByte:
+		return int8(j.Bits)
+	case JWDPTagChar:
+		return uint16(j.Bits)
+	case JWDPTagShort:
+		return int16(j.Bits)
+	case JWDPTagInt:
+		return int32(j.Bits)
+	case JWDPTagLong:
+		return int64(j.Bits)
+	case JWDPTagFloat:
+		return math.Float32frombits(uint32(j.Bits))
+	case JWDPTagDouble:
+		return math.Float64frombits(j.Bits)
+	case JWDPTagVoid:
+		return nil
+	default:
+		if j.Tag.IsObject() {
+			return j.TaggedObjectID()
+		}
+		return nil
+	}
+}
+
+// Bool returns a boolean value
+func (j JWDPValue) Bool() (bool, error) {
+	if j.Tag != JWDPTagBoolean {
+		return false, fmt.Errorf("%v value is not a boolean", j.Tag)
+	}
+	return j.Bits != 0, nil
+}
+
+// Int returns any integral value, including char, widened to int64
+func (j JWDPValue) Int() (int64, error) {
+	switch j.Tag {
+	case JWDPTagByte:
+		return int64(int8(j.Bits)), nil
+	case JWDPTagChar:
+		return int64(uint16(j.Bits)), nil
+	case JWDPTagShort:
+		return int64(int16(j.Bits)), nil
+	case JWDPTagInt:
+		return int64(int32(j.Bits)), nil
+	case JWDPTagLong:
+		return int64(j.Bits), nil
+	default:
+		return 0, fmt.Errorf("%v value is not integral", j.Tag)
+	}
+}
+
+// Float returns a float or double value widened to float64
+func (j JWDPValue) Float() (float64, error) {
+	switch j.Tag {
+	case JWDPTagFloat:
+		return float64(math.Float32frombits(uint32(j.Bits))), nil
+	case JWDPTagDouble:
+		return math.Float64frombits(j.Bits), nil
+	default:
+		return 0, fmt.Errorf("%v value is not floating point", j.Tag)
+	}
+}
+
+// IsObject reports whether the value is an object reference
+func (j JWDPValue) IsObject() bool {
+	return j.Tag.IsObject()
+}
+
+// IsNull reports whether the value is a null object reference
+func (j JWDPValue) IsNull() bool {
+	return j.Tag.IsObject() && j.Bits == 0
+}
+
+// ObjectID returns the objectID of an object value
+func (j JWDPValue) ObjectID() JWDPObjectID {
+	return JWDPObjectID{ObjectID: j.Bits}
+}
+
+// TaggedObjectID returns the tagged-objectID of an object value
+func (j JWDPValue) TaggedObjectID() JWDPTaggedObjectID {
+	return JWDPTaggedObjectID{Tag: j.Tag, ObjectID: j.ObjectID()}
+}
+
+// Untagged returns the value in untagged form
+func (j JWDPValue) Untagged() JWDPUntaggedValue {
+	return JWDPUntaggedValue(j)
+}
@@ -0,0 +1,331 @@
+package basetypes
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type taggedValue struct {
+	Value JWDPValue
+	After byte
+}
+
+type untaggedValue struct {
+	Value JWDPUntaggedValue
+	After byte
+}
+
+type arrayRegion struct {
+	Region JWDPArrayRegion
+	After  byte
+}
+
+// valueTests covers every tag, with the bytes of its untagged form
+// under 8 byte objectIDs
+var valueTests = []struct {
+	value JWDPValue
+	want  []byte
+}{
+	{BooleanValue(true), []byte{1}},
+	{BooleanValue(false), []byte{0}},
+	{ByteValue(-2), []byte{0xFE}},
+	{CharValue('é'), []byte{0, 0xE9}},
+	{ShortValue(-2), []byte{0xFF, 0xFE}},
+	{IntValue(-2), []byte{0xFF, 0xFF, 0xFF, 0xFE}},
+	{LongValue(-2), []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}},
+	{FloatValue(1.5), []byte{0x3F, 0xC0, 0, 0}},
+	{DoubleValue(-1.5), []byte{0xBF, 0xF8, 0, 0, 0, 0, 0, 0}},
+	{VoidValue(), []byte{}},
+	{ObjectValue(JWDPTagObject, JWDPObjectID{ObjectID: 0x11}), []byte{0, 0, 0, 0, 0, 0, 0, 0x11}},
+	{ObjectValue(JWDPTagArray, JWDPObjectID{ObjectID: 0x12}), []byte{0, 0, 0, 0, 0, 0, 0, 0x12}},
+	{ObjectValue(JWDPTagString, JWDPObjectID{ObjectID: 0x13}), []byte{0, 0, 0, 0, 0, 0, 0, 0x13}},
+	{ObjectValue(JWDPTagThread, JWDPObjectID{ObjectID: 0x14}), []byte{0, 0, 0, 0, 0, 0, 0, 0x14}},
+	{ObjectValue(JWDPTagThreadGroup, JWDPObjectID{ObjectID: 0x15}), []byte{0, 0, 0, 0, 0, 0, 0, 0x15}},
+	{ObjectValue(JWDPTagClassLoader, JWDPObjectID{ObjectID: 0x16}), []byte{0, 0, 0, 0, 0, 0, 0, 0x16}},
+	{ObjectValue(JWDPTagClassObject, JWDPObjectID{ObjectID: 0x17}), []byte{0, 0, 0, 0, 0, 0, 0, 0x17}},
+	{NullValue(), []byte{0, 0, 0, 0, 0, 0, 0, 0}},
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	for _, tt := range valueTests {
+		t.Run(tt.value.String(), func(t *testing.T) {
+			in := taggedValue{Value: tt.value, After: 0xEE}
+			got, err := idSizes.Pack(&in)
+			if err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			want := concat([]byte{byte(tt.value.Tag)}, tt.want, []byte{0xEE})
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Pack:\n got % X\nwant % X", got, want)
+			}
+			var out taggedValue
+			if err := idSizes.Unpack(got, &out); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Fatalf("Unpack: got %v, want %v", out, in)
+			}
+		})
+	}
+}
+
+func TestUntaggedValueRoundTrip(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	for _, tt := range valueTests {
+		t.Run(tt.value.String(), func(t *testing.T) {
+			in := untaggedValue{Value: tt.value.Untagged(), After: 0xEE}
+			got, err := idSizes.Pack(&in)
+			if err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			want := concat(tt.want, []byte{0xEE})
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Pack:\n got % X\nwant % X", got, want)
+			}
+			// the tag comes from context, not the wire
+			out := untaggedValue{Value: JWDPUntaggedValue{Tag: tt.value.Tag}}
+			if err := idSizes.Unpack(got, &out); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Fatalf("Unpack: got %v, want %v", out, in)
+			}
+		})
+	}
+}
+
+func TestUntaggedValueUnpackWithoutTag(t *testing.T) {
+	var out untaggedValue
+	err := DefaultIDSizes().Unpack([]byte{0, 0, 0, 1, 0xEE}, &out)
+	if err == nil || !strings.Contains(err.Error(), "tag set before unpacking") {
+		t.Fatalf("Unpack: got %v, want missing tag error", err)
+	}
+}
+
+func TestValueUnknownTag(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	in := taggedValue{Value: JWDPValue{Tag: 'X'}}
+	if _, err := idSizes.Pack(&in); err == nil {
+		t.Fatal("Pack: expected error for unknown tag")
+	}
+	var out taggedValue
+	if err := idSizes.Unpack([]byte{'X', 0, 0, 0, 0, 0xEE}, &out); err == nil {
+		t.Fatal("Unpack: expected error for unknown tag")
+	}
+}
+
+func TestValue4ByteObjectIDs(t *testing.T) {
+	idSizes := DefaultIDSizes()
+	idSizes.ObjectIDSize = 4
+	tests := []struct {
+		name string
+		in   interface{}
+		out  interface{}
+		want []byte
+	}{
+		{
+			name: "tagged",
+			in:   &taggedValue{Value: ObjectValue(JWDPTagString, JWDPObjectID{ObjectID: 0x11}), After: 0xEE},
+			out:  &taggedValue{},
+			want: []byte{'s', 0, 0, 0, 0x11, 0xEE},
+		},
+		{
+			name: "untagged",
+			in:   &untaggedValue{Value: ObjectValue(JWDPTagThread, JWDPObjectID{ObjectID: 0x22}).Untagged(), After: 0xEE},
+			out:  &untaggedValue{Value: JWDPUntaggedValue{Tag: JWDPTagThread}},
+			want: []byte{0, 0, 0, 0x22, 0xEE},
+		},
+		{
+			name: "primitive unaffected",
+			in:   &taggedValue{Value: LongValue(1), After: 0xEE},
+			out:  &taggedValue{},
+			want: []byte{'J', 0, 0, 0, 0, 0, 0, 0, 1, 0xEE},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := idSizes.Pack(tt.in)
+			if err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Pack:\n got % X\nwant % X", got, tt.want)
+			}
+			if err := idSizes.Unpack(got, tt.out); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !reflect.DeepEqual(tt.out, tt.in) {
+				t.Fatalf("Unpack: got %v, want %v", tt.out, tt.in)
+			}
+		})
+	}
+
+	tooBig := taggedValue{Value: ObjectValue(JWDPTagObject, JWDPObjectID{ObjectID: 0x100000000})}
+	if _, err := idSizes.Pack(&tooBig); err == nil {
+		t.Fatal("Pack: expected error for objectID too big for 4 bytes")
+	}
+}
+
+func TestArrayRegionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		idSizes IDSizes
+		region  JWDPArrayRegion
+		want    []byte
+	}{
+		{
+			name:    "ints",
+			idSizes: DefaultIDSizes(),
+			region:  JWDPArrayRegion{Tag: JWDPTagInt, Values: []JWDPValue{IntValue(1), IntValue(-1)}},
+			want: concat(
+				[]byte{'I', 0, 0, 0, 2},
+				[]byte{0, 0, 0, 1},
+				[]byte{0xFF, 0xFF, 0xFF, 0xFF},
+			),
+		},
+		{
+			name:    "booleans",
+			idSizes: DefaultIDSizes(),
+			region:  JWDPArrayRegion{Tag: JWDPTagBoolean, Values: []JWDPValue{BooleanValue(true), BooleanValue(false), BooleanValue(true)}},
+			want:    []byte{'Z', 0, 0, 0, 3, 1, 0, 1},
+		},
+		{
+			name:    "empty",
+			idSizes: DefaultIDSizes(),
+			region:  JWDPArrayRegion{Tag: JWDPTagDouble, Values: []JWDPValue{}},
+			want:    []byte{'D', 0, 0, 0, 0},
+		},
+		{
+			name:    "objects",
+			idSizes: DefaultIDSizes(),
+			region: JWDPArrayRegion{Tag: JWDPTagObject, Values: []JWDPValue{
+				ObjectValue(JWDPTagString, JWDPObjectID{ObjectID: 0x11}),
+				NullValue(),
+			}},
+			want: concat(
+				[]byte{'L', 0, 0, 0, 2},
+				[]byte{'s', 0, 0, 0, 0, 0, 0, 0, 0x11},
+				[]byte{'L', 0, 0, 0, 0, 0, 0, 0, 0},
+			),
+		},
+		{
+			name:    "objects with 4 byte IDs",
+			idSizes: IDSizes{FieldIDSize: 8, MethodIDSize: 8, ObjectIDSize: 4, ReferenceTypeIDSize: 8, FrameIDSize: 8},
+			region: JWDPArrayRegion{Tag: JWDPTagArray, Values: []JWDPValue{
+				ObjectValue(JWDPTagArray, JWDPObjectID{ObjectID: 0x11}),
+				ObjectValue(JWDPTagThread, JWDPObjectID{ObjectID: 0x22}),
+			}},
+			want: concat(
+				[]byte{'[', 0, 0, 0, 2},
+				[]byte{'[', 0, 0, 0, 0x11},
+				[]byte{'t', 0, 0, 0, 0x22},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := arrayRegion{Region: tt.region, After: 0xEE}
+			got, err := tt.idSizes.Pack(&in)
+			if err != nil {
+				t.Fatalf("Pack: %v", err)
+			}
+			want := concat(tt.want, []byte{0xEE})
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Pack:\n got % X\nwant % X", got, want)
+			}
+			var out arrayRegion
+			if err := tt.idSizes.Unpack(got, &out); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Fatalf("Unpack: got %v, want %v", out, in)
+			}
+		})
+	}
+}
+
+func TestArrayRegionMismatchedValue(t *testing.T) {
+	in := arrayRegion{Region: JWDPArrayRegion{Tag: JWDPTagInt, Values: []JWDPValue{LongValue(1)}}}
+	if _, err := DefaultIDSizes().Pack(&in); err == nil {
+		t.Fatal("Pack: expected error for a long in an int array region")
+	}
+}
+
+func TestArrayRegionBadLength(t *testing.T) {
+	var out arrayRegion
+	if err := DefaultIDSizes().Unpack([]byte{'I', 0xFF, 0xFF, 0xFF, 0xFF}, &out); err == nil {
+		t.Fatal("Unpack: expected error for negative length")
+	}
+}
+
+func TestValueIntSignExtension(t *testing.T) {
+	tests := []struct {
+		value JWDPValue
+		want  int64
+	}{
+		{ByteValue(-1), -1},
+		{ByteValue(math.MaxInt8), math.MaxInt8},
+		{ShortValue(math.MinInt16), math.MinInt16},
+		{IntValue(-1), -1},
+		{IntValue(math.MinInt32), math.MinInt32},
+		{LongValue(math.MinInt64), math.MinInt64},
+		// char is unsigned
+		{CharValue(0xFFFF), 0xFFFF},
+		// only the low bits of the tag's size count
+		{JWDPValue{Tag: JWDPTagByte, Bits: 0x1FF}, -1},
+		{JWDPValue{Tag: JWDPTagInt, Bits: 0x1_8000_0000}, math.MinInt32},
+	}
+	for _, tt := range tests {
+		got, err := tt.value.Int()
+		if err != nil {
+			t.Fatalf("%v Int: %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("%v Int: got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// and the same after a round trip through the wire
+	for _, value := range []JWDPValue{ByteValue(-5), ShortValue(-5), IntValue(-5), LongValue(-5)} {
+		in := taggedValue{Value: value}
+		data, err := DefaultIDSizes().Pack(&in)
+		if err != nil {
+			t.Fatalf("Pack: %v", err)
+		}
+		var out taggedValue
+		if err := DefaultIDSizes().Unpack(data, &out); err != nil {
+			t.Fatalf("Unpack: %v", err)
+		}
+		if got, _ := out.Value.Int(); got != -5 {
+			t.Errorf("%v after round trip: Int got %v, want -5", value, got)
+		}
+	}
+
+	for _, value := range []JWDPValue{BooleanValue(true), FloatValue(1), NullValue()} {
+		if _, err := value.Int(); err == nil {
+			t.Errorf("%v Int: expected error", value)
+		}
+	}
+}
+
+func TestNewValueInterface(t *testing.T) {
+	for _, v := range []interface{}{
+		true, int8(-1), uint16('x'), int16(-1), int32(-1), int64(-1), float32(1.5), float64(-1.5),
+		JWDPTaggedObjectID{Tag: JWDPTagThread, ObjectID: JWDPObjectID{ObjectID: 7}},
+	} {
+		value, err := NewValue(v)
+		if err != nil {
+			t.Fatalf("NewValue(%T): %v", v, err)
+		}
+		if got := value.Interface(); !reflect.DeepEqual(got, v) {
+			t.Errorf("NewValue(%T): Interface got %#v, want %#v", v, got, v)
+		}
+	}
+	if _, err := NewValue(1); err == nil {
+		t.Fatal("NewValue(int): expected error")
+	}
+}
@@ -0,0 +1,82 @@
+package classtype
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// SuperclassCommand represents the superclass command
+var SuperclassCommand = jdwp.Command{Commandset: 3, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// SuperclassCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_Superclass
+type SuperclassCommandData struct {
+	Clazz basetypes.JWDPRefTypeID
+}
+
+// SuperclassReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_Superclass
+type SuperclassReply struct {
+	// Superclass is null for java.lang.Object
+	Superclass basetypes.JWDPRefTypeID
+}
+
+// InvokeMethodCommand represents the invoke method command
+var InvokeMethodCommand = jdwp.Command{Commandset: 3, Command: 3, HasCommandData: true, HasReplyData: true}
+
+// InvokeMethodCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_InvokeMethod
+type InvokeMethodCommandData struct {
+	Clazz        basetypes.JWDPRefTypeID
+	Thread       common.ThreadID
+	MethodID     basetypes.JWDPMethodID
+	NumArguments int32
+	Arguments    []basetypes.JWDPValue `struct:"sizefrom=NumArguments"`
+	Options      common.InvokeOptions
+}
+
+// InvokeMethodReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_InvokeMethod
+type InvokeMethodReply struct {
+	ReturnValue basetypes.JWDPValue
+	// Exception is null unless the method threw
+	Exception basetypes.JWDPTaggedObjectID
+}
+
+func (i *InvokeMethodReply) String() string {
+	return fmt.Sprintf("ReturnValue: %s Exception: %s",
+		i.ReturnValue.String(),
+		i.Exception.String())
+}
+
+// NewInstanceCommand represents the new instance command
+var NewInstanceCommand = jdwp.Command{Commandset: 3, Command: 4, HasCommandData: true, HasReplyData: true}
+
+// NewInstanceCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_NewInstance
+type NewInstanceCommandData struct {
+	Clazz  basetypes.JWDPRefTypeID
+	Thread common.ThreadID
+	// MethodID is the constructor to run
+	MethodID     basetypes.JWDPMethodID
+	NumArguments int32
+	Arguments    []basetypes.JWDPValue `struct:"sizefrom=NumArguments"`
+	Options      common.InvokeOptions
+}
+
+// NewInstanceReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ClassType_NewInstance
+type NewInstanceReply struct {
+	// NewObject is null if the constructor threw
+	NewObject basetypes.JWDPTaggedObjectID
+	Exception basetypes.JWDPTaggedObjectID
+}
+
+func (n *NewInstanceReply) String() string {
+	return fmt.Sprintf("NewObject: %s Exception: %s",
+		n.NewObject.String(),
+		n.Exception.String())
+}
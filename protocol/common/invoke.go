@@ -0,0 +1,27 @@
+package common
+
+import "strings"
+
+// InvokeOptions represents the options of the InvokeMethod and
+// NewInstance commands
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_InvokeOptions
+type InvokeOptions int32
+
+const (
+	// InvokeSingleThreaded - resume only the invoking thread
+	InvokeSingleThreaded InvokeOptions = 0x01
+	// InvokeNonvirtual - invoke the method in the given class rather
+	// than looking it up in the object's class
+	InvokeNonvirtual InvokeOptions = 0x02
+)
+
+func (i InvokeOptions) String() string {
+	var labels []string
+	if i&InvokeSingleThreaded != 0 {
+		labels = append(labels, "SingleThreaded")
+	}
+	if i&InvokeNonvirtual != 0 {
+		labels = append(labels, "Nonvirtual")
+	}
+	return "{" + strings.Join(labels, "|") + "}"
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// Location represents an executable location
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Location
+type Location struct {
+	TypeTag  basetypes.JWDPTypeTag
+	ClassID  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+	Index    uint64
+}
+
+func (l *Location) String() string {
+	return fmt.Sprintf("TypeTag: %v ClassID: %s MethodID: %s Index: %v",
+		l.TypeTag.String(),
+		l.ClassID.String(),
+		l.MethodID.String(),
+		l.Index)
+}
@@ -0,0 +1,46 @@
+package common
+
+import "strings"
+
+// SignatureToClassName converts a JNI type signature such as
+// "Ljava/lang/String;" or "[I" to a Java type name such as
+// "java.lang.String" or "int[]"
+func SignatureToClassName(signature string) string {
+	dimensions := 0
+	for dimensions < len(signature) && signature[dimensions] == '[' {
+		dimensions++
+	}
+	component := signature[dimensions:]
+	var name string
+	switch {
+	case strings.HasPrefix(component, "L") && strings.HasSuffix(component, ";"):
+		name = strings.Replace(component[1:len(component)-1], "/", ".", -1)
+	case component == "Z":
+		name = "boolean"
+	case component == "B":
+		name = "byte"
+	case component == "C":
+		name = "char"
+	case component == "S":
+		name = "short"
+	case component == "I":
+		name = "int"
+	case component == "J":
+		name = "long"
+	case component == "F":
+		name = "float"
+	case component == "D":
+		name = "double"
+	case component == "V":
+		name = "void"
+	default:
+		name = component
+	}
+	return name + strings.Repeat("[]", dimensions)
+}
+
+// ClassNameToSignature converts a fully qualified class name such as
+// "java.lang.String" to its JNI signature "Ljava/lang/String;"
+func ClassNameToSignature(className string) string {
+	return "L" + strings.Replace(className, ".", "/", -1) + ";"
+}
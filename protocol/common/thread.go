@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/jquirke/jdwpgo/protocol/basetypes"
@@ -13,9 +14,39 @@ func (t *ThreadID) String() string {
 	return fmt.Sprintf("ThreadID: %s", ((*basetypes.JWDPObjectID)(t)).String())
 }
 
+// SizeOf implements restruct.Sizer
+func (t ThreadID) SizeOf() int {
+	return (basetypes.JWDPObjectID)(t).SizeOf()
+}
+
+// Pack implements restruct.Packer
+func (t ThreadID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return (basetypes.JWDPObjectID)(t).Pack(buf, order)
+}
+
+// Unpack implements restruct.Unpacker
+func (t *ThreadID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return ((*basetypes.JWDPObjectID)(t)).Unpack(buf, order)
+}
+
 // ThreadGroupID represents a thread Group ID
 type ThreadGroupID basetypes.JWDPObjectID
 
 func (t *ThreadGroupID) String() string {
 	return fmt.Sprintf("ThreadGroupID: %s", ((*basetypes.JWDPObjectID)(t)).String())
 }
+
+// SizeOf implements restruct.Sizer
+func (t ThreadGroupID) SizeOf() int {
+	return (basetypes.JWDPObjectID)(t).SizeOf()
+}
+
+// Pack implements restruct.Packer
+func (t ThreadGroupID) Pack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return (basetypes.JWDPObjectID)(t).Pack(buf, order)
+}
+
+// Unpack implements restruct.Unpacker
+func (t *ThreadGroupID) Unpack(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	return ((*basetypes.JWDPObjectID)(t)).Unpack(buf, order)
+}
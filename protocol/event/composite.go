@@ -0,0 +1,73 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// CompositeCommand represents the composite command, sent by the VM
+var CompositeCommand = jdwp.Command{Commandset: 64, Command: 100, HasCommandData: true}
+
+// Composite represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type Composite struct {
+	SuspendPolicy SuspendPolicy
+	Events        []Event
+}
+
+func (c *Composite) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("SuspendPolicy: %v\n", c.SuspendPolicy))
+	for _, event := range c.Events {
+		builder.WriteString(fmt.Sprintf("%s\n", event.String()))
+	}
+	return builder.String()
+}
+
+type compositeHeader struct {
+	SuspendPolicy SuspendPolicy
+	NumEvents     int32
+}
+
+type eventHeader struct {
+	EventKind Kind
+}
+
+// DecodeComposite decodes the command data of a composite command
+func DecodeComposite(idSizes basetypes.IDSizes, data []byte) (*Composite, error) {
+	var header compositeHeader
+	rest, err := idSizes.UnpackPrefix(data, &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.NumEvents < 0 {
+		return nil, fmt.Errorf("invalid event count: %v", header.NumEvents)
+	}
+	composite := &Composite{
+		SuspendPolicy: header.SuspendPolicy,
+		Events:        make([]Event, 0, header.NumEvents),
+	}
+	for idx := int32(0); idx < header.NumEvents; idx++ {
+		var eventHeader eventHeader
+		rest, err = idSizes.UnpackPrefix(rest, &eventHeader)
+		if err != nil {
+			return nil, err
+		}
+		event, err := newEvent(eventHeader.EventKind)
+		if err != nil {
+			return nil, err
+		}
+		rest, err = idSizes.UnpackPrefix(rest, event)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %v event: %v", eventHeader.EventKind, err)
+		}
+		composite.Events = append(composite.Events, event)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%v trailing bytes after composite", len(rest))
+	}
+	return composite, nil
+}
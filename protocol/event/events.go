@@ -0,0 +1,535 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+// Event is implemented by every event carried in a Composite
+type Event interface {
+	Kind() Kind
+	// EventRequestID is the ID of the request that generated the event,
+	// or 0 for events the VM generates automatically
+	EventRequestID() int32
+	String() string
+}
+
+// newEvent returns an empty event of the given kind to unpack into
+func newEvent(kind Kind) (Event, error) {
+	switch kind {
+	case KindVMStart:
+		return &VMStart{}, nil
+	case KindSingleStep:
+		return &SingleStep{}, nil
+	case KindBreakpoint:
+		return &Breakpoint{}, nil
+	case KindMethodEntry:
+		return &MethodEntry{}, nil
+	case KindMethodExit:
+		return &MethodExit{}, nil
+	case KindMethodExitWithReturnValue:
+		return &MethodExitWithReturnValue{}, nil
+	case KindMonitorContendedEnter:
+		return &MonitorContendedEnter{}, nil
+	case KindMonitorContendedEntered:
+		return &MonitorContendedEntered{}, nil
+	case KindMonitorWait:
+		return &MonitorWait{}, nil
+	case KindMonitorWaited:
+		return &MonitorWaited{}, nil
+	case KindException:
+		return &Exception{}, nil
+	case KindThreadStart:
+		return &ThreadStart{}, nil
+	case KindThreadDeath:
+		return &ThreadDeath{}, nil
+	case KindClassPrepare:
+		return &ClassPrepare{}, nil
+	case KindClassUnload:
+		return &ClassUnload{}, nil
+	case KindFieldAccess:
+		return &FieldAccess{}, nil
+	case KindFieldModification:
+		return &FieldModification{}, nil
+	case KindVMDeath:
+		return &VMDeath{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported event kind: %v", byte(kind))
+	}
+}
+
+func formatFields(kind Kind, requestID int32, fields ...interface{}) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("{%v RequestID: %v", kind, requestID))
+	for idx := 0; idx+1 < len(fields); idx += 2 {
+		builder.WriteString(fmt.Sprintf(" %v: %v", fields[idx], fields[idx+1]))
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// VMStart represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type VMStart struct {
+	RequestID int32
+	Thread    common.ThreadID
+}
+
+// Kind implements Event
+func (e *VMStart) Kind() Kind {
+	return KindVMStart
+}
+
+// EventRequestID implements Event
+func (e *VMStart) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *VMStart) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String())
+}
+
+// SingleStep represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type SingleStep struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *SingleStep) Kind() Kind {
+	return KindSingleStep
+}
+
+// EventRequestID implements Event
+func (e *SingleStep) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *SingleStep) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String())
+}
+
+// Breakpoint represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type Breakpoint struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *Breakpoint) Kind() Kind {
+	return KindBreakpoint
+}
+
+// EventRequestID implements Event
+func (e *Breakpoint) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *Breakpoint) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String())
+}
+
+// MethodEntry represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MethodEntry struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *MethodEntry) Kind() Kind {
+	return KindMethodEntry
+}
+
+// EventRequestID implements Event
+func (e *MethodEntry) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MethodEntry) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String())
+}
+
+// MethodExit represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MethodExit struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *MethodExit) Kind() Kind {
+	return KindMethodExit
+}
+
+// EventRequestID implements Event
+func (e *MethodExit) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MethodExit) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String())
+}
+
+// MethodExitWithReturnValue represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MethodExitWithReturnValue struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Location  common.Location
+	Value     basetypes.JWDPValue
+}
+
+// Kind implements Event
+func (e *MethodExitWithReturnValue) Kind() Kind {
+	return KindMethodExitWithReturnValue
+}
+
+// EventRequestID implements Event
+func (e *MethodExitWithReturnValue) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MethodExitWithReturnValue) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String(),
+		"Value", e.Value.String())
+}
+
+// MonitorContendedEnter represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MonitorContendedEnter struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Object    basetypes.JWDPTaggedObjectID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *MonitorContendedEnter) Kind() Kind {
+	return KindMonitorContendedEnter
+}
+
+// EventRequestID implements Event
+func (e *MonitorContendedEnter) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MonitorContendedEnter) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Object", e.Object.String(),
+		"Location", e.Location.String())
+}
+
+// MonitorContendedEntered represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MonitorContendedEntered struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Object    basetypes.JWDPTaggedObjectID
+	Location  common.Location
+}
+
+// Kind implements Event
+func (e *MonitorContendedEntered) Kind() Kind {
+	return KindMonitorContendedEntered
+}
+
+// EventRequestID implements Event
+func (e *MonitorContendedEntered) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MonitorContendedEntered) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Object", e.Object.String(),
+		"Location", e.Location.String())
+}
+
+// MonitorWait represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MonitorWait struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Object    basetypes.JWDPTaggedObjectID
+	Location  common.Location
+	Timeout   int64
+}
+
+// Kind implements Event
+func (e *MonitorWait) Kind() Kind {
+	return KindMonitorWait
+}
+
+// EventRequestID implements Event
+func (e *MonitorWait) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MonitorWait) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Object", e.Object.String(),
+		"Location", e.Location.String(),
+		"Timeout", e.Timeout)
+}
+
+// MonitorWaited represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type MonitorWaited struct {
+	RequestID int32
+	Thread    common.ThreadID
+	Object    basetypes.JWDPTaggedObjectID
+	Location  common.Location
+	TimedOut  bool
+}
+
+// Kind implements Event
+func (e *MonitorWaited) Kind() Kind {
+	return KindMonitorWaited
+}
+
+// EventRequestID implements Event
+func (e *MonitorWaited) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *MonitorWaited) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Object", e.Object.String(),
+		"Location", e.Location.String(),
+		"TimedOut", e.TimedOut)
+}
+
+// Exception represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type Exception struct {
+	RequestID     int32
+	Thread        common.ThreadID
+	Location      common.Location
+	Exception     basetypes.JWDPTaggedObjectID
+	CatchLocation common.Location
+}
+
+// Kind implements Event
+func (e *Exception) Kind() Kind {
+	return KindException
+}
+
+// EventRequestID implements Event
+func (e *Exception) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *Exception) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String(),
+		"Exception", e.Exception.String(),
+		"CatchLocation", e.CatchLocation.String())
+}
+
+// ThreadStart represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type ThreadStart struct {
+	RequestID int32
+	Thread    common.ThreadID
+}
+
+// Kind implements Event
+func (e *ThreadStart) Kind() Kind {
+	return KindThreadStart
+}
+
+// EventRequestID implements Event
+func (e *ThreadStart) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *ThreadStart) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String())
+}
+
+// ThreadDeath represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type ThreadDeath struct {
+	RequestID int32
+	Thread    common.ThreadID
+}
+
+// Kind implements Event
+func (e *ThreadDeath) Kind() Kind {
+	return KindThreadDeath
+}
+
+// EventRequestID implements Event
+func (e *ThreadDeath) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *ThreadDeath) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String())
+}
+
+// ClassPrepare represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type ClassPrepare struct {
+	RequestID  int32
+	Thread     common.ThreadID
+	RefTypeTag basetypes.JWDPTypeTag
+	TypeID     basetypes.JWDPRefTypeID
+	Signature  basetypes.JDWPString
+	Status     vm.AllClassClassStatus
+}
+
+// Kind implements Event
+func (e *ClassPrepare) Kind() Kind {
+	return KindClassPrepare
+}
+
+// EventRequestID implements Event
+func (e *ClassPrepare) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *ClassPrepare) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"RefTypeTag", e.RefTypeTag,
+		"TypeID", e.TypeID.String(),
+		"Signature", e.Signature.String(),
+		"Status", e.Status)
+}
+
+// ClassUnload represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type ClassUnload struct {
+	RequestID int32
+	Signature basetypes.JDWPString
+}
+
+// Kind implements Event
+func (e *ClassUnload) Kind() Kind {
+	return KindClassUnload
+}
+
+// EventRequestID implements Event
+func (e *ClassUnload) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *ClassUnload) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Signature", e.Signature.String())
+}
+
+// FieldAccess represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type FieldAccess struct {
+	RequestID  int32
+	Thread     common.ThreadID
+	Location   common.Location
+	RefTypeTag basetypes.JWDPTypeTag
+	TypeID     basetypes.JWDPRefTypeID
+	FieldID    basetypes.JWDPFieldID
+	Object     basetypes.JWDPTaggedObjectID
+}
+
+// Kind implements Event
+func (e *FieldAccess) Kind() Kind {
+	return KindFieldAccess
+}
+
+// EventRequestID implements Event
+func (e *FieldAccess) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *FieldAccess) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String(),
+		"RefTypeTag", e.RefTypeTag,
+		"TypeID", e.TypeID.String(),
+		"FieldID", e.FieldID.String(),
+		"Object", e.Object.String())
+}
+
+// FieldModification represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type FieldModification struct {
+	RequestID  int32
+	Thread     common.ThreadID
+	Location   common.Location
+	RefTypeTag basetypes.JWDPTypeTag
+	TypeID     basetypes.JWDPRefTypeID
+	FieldID    basetypes.JWDPFieldID
+	Object     basetypes.JWDPTaggedObjectID
+	ValueToBe  basetypes.JWDPValue
+}
+
+// Kind implements Event
+func (e *FieldModification) Kind() Kind {
+	return KindFieldModification
+}
+
+// EventRequestID implements Event
+func (e *FieldModification) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *FieldModification) String() string {
+	return formatFields(e.Kind(), e.RequestID,
+		"Thread", e.Thread.String(),
+		"Location", e.Location.String(),
+		"RefTypeTag", e.RefTypeTag,
+		"TypeID", e.TypeID.String(),
+		"FieldID", e.FieldID.String(),
+		"Object", e.Object.String(),
+		"ValueToBe", e.ValueToBe.String())
+}
+
+// VMDeath represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Event_Composite
+type VMDeath struct {
+	RequestID int32
+}
+
+// Kind implements Event
+func (e *VMDeath) Kind() Kind {
+	return KindVMDeath
+}
+
+// EventRequestID implements Event
+func (e *VMDeath) EventRequestID() int32 {
+	return e.RequestID
+}
+
+func (e *VMDeath) String() string {
+	return formatFields(e.Kind(), e.RequestID)
+}
@@ -0,0 +1,133 @@
+package event
+
+// Kind represents an event kind
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_EventKind
+type Kind byte
+
+const (
+	// KindSingleStep - single step
+	KindSingleStep Kind = 1
+	// KindBreakpoint - breakpoint
+	KindBreakpoint Kind = 2
+	// KindFramePop - frame pop
+	KindFramePop Kind = 3
+	// KindException - exception
+	KindException Kind = 4
+	// KindUserDefined - user defined
+	KindUserDefined Kind = 5
+	// KindThreadStart - thread start
+	KindThreadStart Kind = 6
+	// KindThreadDeath - thread death
+	KindThreadDeath Kind = 7
+	// KindClassPrepare - class prepare
+	KindClassPrepare Kind = 8
+	// KindClassUnload - class unload
+	KindClassUnload Kind = 9
+	// KindClassLoad - class load
+	KindClassLoad Kind = 10
+	// KindFieldAccess - field access
+	KindFieldAccess Kind = 20
+	// KindFieldModification - field modification
+	KindFieldModification Kind = 21
+	// KindExceptionCatch - exception catch
+	KindExceptionCatch Kind = 30
+	// KindMethodEntry - method entry
+	KindMethodEntry Kind = 40
+	// KindMethodExit - method exit
+	KindMethodExit Kind = 41
+	// KindMethodExitWithReturnValue - method exit with return value
+	KindMethodExitWithReturnValue Kind = 42
+	// KindMonitorContendedEnter - monitor contended enter
+	KindMonitorContendedEnter Kind = 43
+	// KindMonitorContendedEntered - monitor contended entered
+	KindMonitorContendedEntered Kind = 44
+	// KindMonitorWait - monitor wait
+	KindMonitorWait Kind = 45
+	// KindMonitorWaited - monitor waited
+	KindMonitorWaited Kind = 46
+	// KindVMStart - VM start
+	KindVMStart Kind = 90
+	// KindVMDeath - VM death
+	KindVMDeath Kind = 99
+	// KindVMDisconnected - VM disconnected, never sent across the wire
+	KindVMDisconnected Kind = 100
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSingleStep:
+		return "SingleStep"
+	case KindBreakpoint:
+		return "Breakpoint"
+	case KindFramePop:
+		return "FramePop"
+	case KindException:
+		return "Exception"
+	case KindUserDefined:
+		return "UserDefined"
+	case KindThreadStart:
+		return "ThreadStart"
+	case KindThreadDeath:
+		return "ThreadDeath"
+	case KindClassPrepare:
+		return "ClassPrepare"
+	case KindClassUnload:
+		return "ClassUnload"
+	case KindClassLoad:
+		return "ClassLoad"
+	case KindFieldAccess:
+		return "FieldAccess"
+	case KindFieldModification:
+		return "FieldModification"
+	case KindExceptionCatch:
+		return "ExceptionCatch"
+	case KindMethodEntry:
+		return "MethodEntry"
+	case KindMethodExit:
+		return "MethodExit"
+	case KindMethodExitWithReturnValue:
+		return "MethodExitWithReturnValue"
+	case KindMonitorContendedEnter:
+		return "MonitorContendedEnter"
+	case KindMonitorContendedEntered:
+		return "MonitorContendedEntered"
+	case KindMonitorWait:
+		return "MonitorWait"
+	case KindMonitorWaited:
+		return "MonitorWaited"
+	case KindVMStart:
+		return "VMStart"
+	case KindVMDeath:
+		return "VMDeath"
+	case KindVMDisconnected:
+		return "VMDisconnected"
+	default:
+		return "Unknown"
+	}
+}
+
+// SuspendPolicy represents a suspend policy
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_SuspendPolicy
+type SuspendPolicy byte
+
+const (
+	// SuspendPolicyNone - suspend no threads
+	SuspendPolicyNone SuspendPolicy = 0
+	// SuspendPolicyEventThread - suspend the event thread
+	SuspendPolicyEventThread SuspendPolicy = 1
+	// SuspendPolicyAll - suspend all threads
+	SuspendPolicyAll SuspendPolicy = 2
+)
+
+func (s SuspendPolicy) String() string {
+	switch s {
+	case SuspendPolicyNone:
+		return "None"
+	case SuspendPolicyEventThread:
+		return "EventThread"
+	case SuspendPolicyAll:
+		return "All"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,118 @@
+package eventrequest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/event"
+)
+
+// SetCommand represents the set command
+var SetCommand = jdwp.Command{Commandset: 15, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// SetCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_EventRequest_Set
+//
+// Modifiers are added with the builder methods in modifiers.go, and
+// are applied by the VM in the order they were added
+type SetCommandData struct {
+	EventKind     event.Kind
+	SuspendPolicy event.SuspendPolicy
+	Modifiers     []Modifier
+}
+
+// New creates a new event request for the given kind and suspend policy
+func New(eventKind event.Kind, suspendPolicy event.SuspendPolicy) *SetCommandData {
+	return &SetCommandData{
+		EventKind:     eventKind,
+		SuspendPolicy: suspendPolicy,
+	}
+}
+
+type setCommandDataHeader struct {
+	EventKind     event.Kind
+	SuspendPolicy event.SuspendPolicy
+	NumModifiers  int32
+}
+
+// PackIDSized implements basetypes.IDSizedPacker
+func (s *SetCommandData) PackIDSized(idSizes basetypes.IDSizes) ([]byte, error) {
+	data, err := idSizes.Pack(&setCommandDataHeader{
+		EventKind:     s.EventKind,
+		SuspendPolicy: s.SuspendPolicy,
+		NumModifiers:  int32(len(s.Modifiers)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, modifier := range s.Modifiers {
+		modifierData, err := idSizes.Pack(modifier)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(modifier.ModKind()))
+		data = append(data, modifierData...)
+	}
+	return data, nil
+}
+
+// DecodeSetCommandData decodes set command data, the inverse of PackIDSized
+func DecodeSetCommandData(idSizes basetypes.IDSizes, data []byte) (*SetCommandData, error) {
+	var header setCommandDataHeader
+	rest, err := idSizes.UnpackPrefix(data, &header)
+	if err != nil {
+		return nil, err
+	}
+	if header.NumModifiers < 0 {
+		return nil, fmt.Errorf("invalid modifier count: %v", header.NumModifiers)
+	}
+	setCommandData := New(header.EventKind, header.SuspendPolicy)
+	for idx := int32(0); idx < header.NumModifiers; idx++ {
+		if len(rest) < 1 {
+			return nil, fmt.Errorf("missing modifier %v of %v", idx, header.NumModifiers)
+		}
+		modifier, err := newModifier(ModKind(rest[0]))
+		if err != nil {
+			return nil, err
+		}
+		rest, err = idSizes.UnpackPrefix(rest[1:], modifier)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %v modifier: %v", modifier.ModKind(), err)
+		}
+		setCommandData.With(modifier)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%v trailing bytes after modifiers", len(rest))
+	}
+	return setCommandData, nil
+}
+
+func (s *SetCommandData) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("EventKind: %v SuspendPolicy: %v\n", s.EventKind, s.SuspendPolicy))
+	for _, modifier := range s.Modifiers {
+		builder.WriteString(fmt.Sprintf("%v: %+v\n", modifier.ModKind(), modifier))
+	}
+	return builder.String()
+}
+
+// SetReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_EventRequest_Set
+type SetReply struct {
+	RequestID int32
+}
+
+// ClearCommand represents the clear command
+var ClearCommand = jdwp.Command{Commandset: 15, Command: 2, HasCommandData: true}
+
+// ClearCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_EventRequest_Clear
+type ClearCommandData struct {
+	EventKind event.Kind
+	RequestID int32
+}
+
+// ClearAllBreakpointsCommand represents the clear all breakpoints command
+var ClearAllBreakpointsCommand = jdwp.Command{Commandset: 15, Command: 3}
@@ -0,0 +1,327 @@
+package eventrequest
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// ModKind represents the kind of an event request modifier
+type ModKind byte
+
+const (
+	// ModKindCount - report once the event has occurred count times
+	ModKindCount ModKind = 1
+	// ModKindConditional - reserved for future use
+	ModKindConditional ModKind = 2
+	// ModKindThreadOnly - restrict to a thread
+	ModKindThreadOnly ModKind = 3
+	// ModKindClassOnly - restrict to a class and its subtypes
+	ModKindClassOnly ModKind = 4
+	// ModKindClassMatch - restrict to classes matching a pattern
+	ModKindClassMatch ModKind = 5
+	// ModKindClassExclude - exclude classes matching a pattern
+	ModKindClassExclude ModKind = 6
+	// ModKindLocationOnly - restrict to a location
+	ModKindLocationOnly ModKind = 7
+	// ModKindExceptionOnly - restrict exceptions by type and catch state
+	ModKindExceptionOnly ModKind = 8
+	// ModKindFieldOnly - restrict to a field
+	ModKindFieldOnly ModKind = 9
+	// ModKindStep - restrict step events to a thread, size and depth
+	ModKindStep ModKind = 10
+	// ModKindInstanceOnly - restrict to an object instance
+	ModKindInstanceOnly ModKind = 11
+	// ModKindSourceNameMatch - restrict to classes with a matching source name
+	ModKindSourceNameMatch ModKind = 12
+)
+
+func (m ModKind) String() string {
+	switch m {
+	case ModKindCount:
+		return "Count"
+	case ModKindConditional:
+		return "Conditional"
+	case ModKindThreadOnly:
+		return "ThreadOnly"
+	case ModKindClassOnly:
+		return "ClassOnly"
+	case ModKindClassMatch:
+		return "ClassMatch"
+	case ModKindClassExclude:
+		return "ClassExclude"
+	case ModKindLocationOnly:
+		return "LocationOnly"
+	case ModKindExceptionOnly:
+		return "ExceptionOnly"
+	case ModKindFieldOnly:
+		return "FieldOnly"
+	case ModKindStep:
+		return "Step"
+	case ModKindInstanceOnly:
+		return "InstanceOnly"
+	case ModKindSourceNameMatch:
+		return "SourceNameMatch"
+	default:
+		return "Unknown"
+	}
+}
+
+// StepSize represents the granularity of a step
+type StepSize int32
+
+const (
+	// StepSizeMin - step by the minimum possible amount
+	StepSizeMin StepSize = 0
+	// StepSizeLine - step to the next source line
+	StepSizeLine StepSize = 1
+)
+
+func (s StepSize) String() string {
+	switch s {
+	case StepSizeMin:
+		return "Min"
+	case StepSizeLine:
+		return "Line"
+	default:
+		return "Unknown"
+	}
+}
+
+// StepDepth represents how a step treats method calls
+type StepDepth int32
+
+const (
+	// StepDepthInto - step into method calls
+	StepDepthInto StepDepth = 0
+	// StepDepthOver - step over method calls
+	StepDepthOver StepDepth = 1
+	// StepDepthOut - step out of the current method
+	StepDepthOut StepDepth = 2
+)
+
+func (s StepDepth) String() string {
+	switch s {
+	case StepDepthInto:
+		return "Into"
+	case StepDepthOver:
+		return "Over"
+	case StepDepthOut:
+		return "Out"
+	default:
+		return "Unknown"
+	}
+}
+
+// Modifier represents an event request modifier; the concrete types
+// hold the modifier's fields in wire order
+type Modifier interface {
+	ModKind() ModKind
+}
+
+// CountModifier represents the Count modifier
+type CountModifier struct {
+	Count int32
+}
+
+// ConditionalModifier represents the Conditional modifier
+type ConditionalModifier struct {
+	ExprID int32
+}
+
+// ThreadOnlyModifier represents the ThreadOnly modifier
+type ThreadOnlyModifier struct {
+	Thread common.ThreadID
+}
+
+// ClassOnlyModifier represents the ClassOnly modifier
+type ClassOnlyModifier struct {
+	Clazz basetypes.JWDPRefTypeID
+}
+
+// ClassMatchModifier represents the ClassMatch modifier
+type ClassMatchModifier struct {
+	ClassPattern basetypes.JDWPString
+}
+
+// ClassExcludeModifier represents the ClassExclude modifier
+type ClassExcludeModifier struct {
+	ClassPattern basetypes.JDWPString
+}
+
+// LocationOnlyModifier represents the LocationOnly modifier
+type LocationOnlyModifier struct {
+	Location common.Location
+}
+
+// ExceptionOnlyModifier represents the ExceptionOnly modifier
+type ExceptionOnlyModifier struct {
+	ExceptionOrNull basetypes.JWDPRefTypeID
+	Caught          bool
+	Uncaught        bool
+}
+
+// FieldOnlyModifier represents the FieldOnly modifier
+type FieldOnlyModifier struct {
+	Declaring basetypes.JWDPRefTypeID
+	FieldID   basetypes.JWDPFieldID
+}
+
+// StepModifier represents the Step modifier
+type StepModifier struct {
+	Thread common.ThreadID
+	Size   StepSize
+	Depth  StepDepth
+}
+
+// InstanceOnlyModifier represents the InstanceOnly modifier
+type InstanceOnlyModifier struct {
+	Instance basetypes.JWDPObjectID
+}
+
+// SourceNameMatchModifier represents the SourceNameMatch modifier
+type SourceNameMatchModifier struct {
+	SourceNamePattern basetypes.JDWPString
+}
+
+// newModifier returns an empty modifier of the given kind to unpack into
+func newModifier(modKind ModKind) (Modifier, error) {
+	switch modKind {
+	case ModKindCount:
+		return &CountModifier{}, nil
+	case ModKindConditional:
+		return &ConditionalModifier{}, nil
+	case ModKindThreadOnly:
+		return &ThreadOnlyModifier{}, nil
+	case ModKindClassOnly:
+		return &ClassOnlyModifier{}, nil
+	case ModKindClassMatch:
+		return &ClassMatchModifier{}, nil
+	case ModKindClassExclude:
+		return &ClassExcludeModifier{}, nil
+	case ModKindLocationOnly:
+		return &LocationOnlyModifier{}, nil
+	case ModKindExceptionOnly:
+		return &ExceptionOnlyModifier{}, nil
+	case ModKindFieldOnly:
+		return &FieldOnlyModifier{}, nil
+	case ModKindStep:
+		return &StepModifier{}, nil
+	case ModKindInstanceOnly:
+		return &InstanceOnlyModifier{}, nil
+	case ModKindSourceNameMatch:
+		return &SourceNameMatchModifier{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported modifier kind: %v", byte(modKind))
+	}
+}
+
+// ModKind implements Modifier
+func (m *CountModifier) ModKind() ModKind { return ModKindCount }
+
+// ModKind implements Modifier
+func (m *ConditionalModifier) ModKind() ModKind { return ModKindConditional }
+
+// ModKind implements Modifier
+func (m *ThreadOnlyModifier) ModKind() ModKind { return ModKindThreadOnly }
+
+// ModKind implements Modifier
+func (m *ClassOnlyModifier) ModKind() ModKind { return ModKindClassOnly }
+
+// ModKind implements Modifier
+func (m *ClassMatchModifier) ModKind() ModKind { return ModKindClassMatch }
+
+// ModKind implements Modifier
+func (m *ClassExcludeModifier) ModKind() ModKind { return ModKindClassExclude }
+
+// ModKind implements Modifier
+func (m *LocationOnlyModifier) ModKind() ModKind { return ModKindLocationOnly }
+
+// ModKind implements Modifier
+func (m *ExceptionOnlyModifier) ModKind() ModKind { return ModKindExceptionOnly }
+
+// ModKind implements Modifier
+func (m *FieldOnlyModifier) ModKind() ModKind { return ModKindFieldOnly }
+
+// ModKind implements Modifier
+func (m *StepModifier) ModKind() ModKind { return ModKindStep }
+
+// ModKind implements Modifier
+func (m *InstanceOnlyModifier) ModKind() ModKind { return ModKindInstanceOnly }
+
+// ModKind implements Modifier
+func (m *SourceNameMatchModifier) ModKind() ModKind { return ModKindSourceNameMatch }
+
+// Count adds a Count modifier
+func (s *SetCommandData) Count(count int32) *SetCommandData {
+	return s.With(&CountModifier{Count: count})
+}
+
+// Conditional adds a Conditional modifier
+func (s *SetCommandData) Conditional(exprID int32) *SetCommandData {
+	return s.With(&ConditionalModifier{ExprID: exprID})
+}
+
+// ThreadOnly adds a ThreadOnly modifier
+func (s *SetCommandData) ThreadOnly(thread common.ThreadID) *SetCommandData {
+	return s.With(&ThreadOnlyModifier{Thread: thread})
+}
+
+// ClassOnly adds a ClassOnly modifier
+func (s *SetCommandData) ClassOnly(clazz basetypes.JWDPRefTypeID) *SetCommandData {
+	return s.With(&ClassOnlyModifier{Clazz: clazz})
+}
+
+// ClassMatch adds a ClassMatch modifier; the pattern is a class name
+// which may begin or end with '*'
+func (s *SetCommandData) ClassMatch(classPattern string) *SetCommandData {
+	return s.With(&ClassMatchModifier{ClassPattern: basetypes.NewJDWPString(classPattern)})
+}
+
+// ClassExclude adds a ClassExclude modifier; the pattern is a class
+// name which may begin or end with '*'
+func (s *SetCommandData) ClassExclude(classPattern string) *SetCommandData {
+	return s.With(&ClassExcludeModifier{ClassPattern: basetypes.NewJDWPString(classPattern)})
+}
+
+// LocationOnly adds a LocationOnly modifier
+func (s *SetCommandData) LocationOnly(location common.Location) *SetCommandData {
+	return s.With(&LocationOnlyModifier{Location: location})
+}
+
+// ExceptionOnly adds an ExceptionOnly modifier; a zero exceptionOrNull
+// matches all exceptions
+func (s *SetCommandData) ExceptionOnly(exceptionOrNull basetypes.JWDPRefTypeID, caught bool, uncaught bool) *SetCommandData {
+	return s.With(&ExceptionOnlyModifier{
+		ExceptionOrNull: exceptionOrNull,
+		Caught:          caught,
+		Uncaught:        uncaught,
+	})
+}
+
+// FieldOnly adds a FieldOnly modifier
+func (s *SetCommandData) FieldOnly(declaring basetypes.JWDPRefTypeID, fieldID basetypes.JWDPFieldID) *SetCommandData {
+	return s.With(&FieldOnlyModifier{Declaring: declaring, FieldID: fieldID})
+}
+
+// Step adds a Step modifier
+func (s *SetCommandData) Step(thread common.ThreadID, size StepSize, depth StepDepth) *SetCommandData {
+	return s.With(&StepModifier{Thread: thread, Size: size, Depth: depth})
+}
+
+// InstanceOnly adds an InstanceOnly modifier
+func (s *SetCommandData) InstanceOnly(instance basetypes.JWDPObjectID) *SetCommandData {
+	return s.With(&InstanceOnlyModifier{Instance: instance})
+}
+
+// SourceNameMatch adds a SourceNameMatch modifier
+func (s *SetCommandData) SourceNameMatch(sourceNamePattern string) *SetCommandData {
+	return s.With(&SourceNameMatchModifier{SourceNamePattern: basetypes.NewJDWPString(sourceNamePattern)})
+}
+
+// With adds an arbitrary modifier
+func (s *SetCommandData) With(modifier Modifier) *SetCommandData {
+	s.Modifiers = append(s.Modifiers, modifier)
+	return s
+}
@@ -0,0 +1,37 @@
+package interfacetype
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// InvokeMethodCommand represents the invoke method command
+var InvokeMethodCommand = jdwp.Command{Commandset: 5, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// InvokeMethodCommandData represents
+// https://docs.oracle.com/javase/8/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_InterfaceType_InvokeMethod
+type InvokeMethodCommandData struct {
+	Clazz        basetypes.JWDPRefTypeID
+	Thread       common.ThreadID
+	MethodID     basetypes.JWDPMethodID
+	NumArguments int32
+	Arguments    []basetypes.JWDPValue `struct:"sizefrom=NumArguments"`
+	Options      common.InvokeOptions
+}
+
+// InvokeMethodReply represents
+// https://docs.oracle.com/javase/8/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_InterfaceType_InvokeMethod
+type InvokeMethodReply struct {
+	ReturnValue basetypes.JWDPValue
+	// Exception is null unless the method threw
+	Exception basetypes.JWDPTaggedObjectID
+}
+
+func (i *InvokeMethodReply) String() string {
+	return fmt.Sprintf("ReturnValue: %s Exception: %s",
+		i.ReturnValue.String(),
+		i.Exception.String())
+}
@@ -0,0 +1,39 @@
+package method
+
+import (
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// BytecodesCommand represents the bytecodes command
+var BytecodesCommand = jdwp.Command{Commandset: 6, Command: 3, HasCommandData: true, HasReplyData: true}
+
+// BytecodesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_Bytecodes
+type BytecodesCommandData struct {
+	RefType  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+}
+
+// BytecodesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_Bytecodes
+type BytecodesReply struct {
+	NumBytes int32
+	Bytes    []byte `struct:"sizefrom=NumBytes"`
+}
+
+// IsObsoleteCommand represents the is obsolete command
+var IsObsoleteCommand = jdwp.Command{Commandset: 6, Command: 4, HasCommandData: true, HasReplyData: true}
+
+// IsObsoleteCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_IsObsolete
+type IsObsoleteCommandData struct {
+	RefType  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+}
+
+// IsObsoleteReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_IsObsolete
+type IsObsoleteReply struct {
+	IsObsolete bool
+}
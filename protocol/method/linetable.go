@@ -0,0 +1,90 @@
+package method
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// LineTableCommand represents the line table command
+var LineTableCommand = jdwp.Command{Commandset: 6, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// LineTableCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_LineTable
+type LineTableCommandData struct {
+	RefType  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+}
+
+// LineTableReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_LineTable
+type LineTableReply struct {
+	Start    int64
+	End      int64
+	NumLines int32
+	Lines    []Line `struct:"sizefrom=NumLines"`
+}
+
+func (l *LineTableReply) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Start: %v End: %v\n", l.Start, l.End))
+	for _, line := range l.Lines {
+		builder.WriteString(fmt.Sprintf("{%s}\n", line.String()))
+	}
+	return builder.String()
+}
+
+// Line represents a single entry in LineTableReply
+type Line struct {
+	LineCodeIndex int64
+	LineNumber    int32
+}
+
+func (l *Line) String() string {
+	return fmt.Sprintf("LineCodeIndex: %v LineNumber: %v", l.LineCodeIndex, l.LineNumber)
+}
+
+// LineForIndex returns the line number containing the code index, or
+// -1 if the index is outside the method or the table has no entry
+// covering it
+func (l *LineTableReply) LineForIndex(index int64) int32 {
+	if index < l.Start || index > l.End {
+		return -1
+	}
+	lineNumber := int32(-1)
+	bestIndex := int64(-1)
+	for _, line := range l.Lines {
+		if line.LineCodeIndex <= index && line.LineCodeIndex > bestIndex {
+			bestIndex = line.LineCodeIndex
+			lineNumber = line.LineNumber
+		}
+	}
+	return lineNumber
+}
+
+// IndicesForLine returns the code indices at which code for a line
+// number starts, lowest first. A line can have several, for example
+// a loop condition, and none if no code was generated for it
+func (l *LineTableReply) IndicesForLine(lineNumber int32) []int64 {
+	var indices []int64
+	for _, line := range l.Lines {
+		if line.LineNumber == lineNumber {
+			indices = append(indices, line.LineCodeIndex)
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
+
+// HasLine reports whether the table has code for a line number
+func (l *LineTableReply) HasLine(lineNumber int32) bool {
+	for _, line := range l.Lines {
+		if line.LineNumber == lineNumber {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package method
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// VariableTableCommand represents the variable table command
+var VariableTableCommand = jdwp.Command{Commandset: 6, Command: 2, HasCommandData: true, HasReplyData: true}
+
+// VariableTableCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_VariableTable
+type VariableTableCommandData struct {
+	RefType  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+}
+
+// VariableTableReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_VariableTable
+type VariableTableReply struct {
+	// ArgCnt is the number of words in the frame used by arguments,
+	// including this for instance methods
+	ArgCnt   int32
+	NumSlots int32
+	Slots    []Variable `struct:"sizefrom=NumSlots"`
+}
+
+func (v *VariableTableReply) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("ArgCnt: %v\n", v.ArgCnt))
+	for _, variable := range v.Slots {
+		builder.WriteString(fmt.Sprintf("{%s}\n", variable.String()))
+	}
+	return builder.String()
+}
+
+// VisibleAt returns the variables in scope at a code index
+func (v *VariableTableReply) VisibleAt(index int64) []Variable {
+	var visible []Variable
+	for _, variable := range v.Slots {
+		if variable.InScope(index) {
+			visible = append(visible, variable)
+		}
+	}
+	return visible
+}
+
+// Variable represents a single variable in VariableTableReply
+type Variable struct {
+	// CodeIndex is the first code index at which the variable is in scope
+	CodeIndex int64
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	// Length is the number of code indices the variable is in scope for
+	Length int32
+	Slot   int32
+}
+
+func (v *Variable) String() string {
+	return fmt.Sprintf("CodeIndex: %v Name: %s Signature: %s Length: %v Slot: %v",
+		v.CodeIndex,
+		v.Name.String(),
+		v.Signature.String(),
+		v.Length,
+		v.Slot)
+}
+
+// InScope reports whether the variable is in scope at a code index
+func (v *Variable) InScope(index int64) bool {
+	return index >= v.CodeIndex && index < v.CodeIndex+int64(v.Length)
+}
+
+// VariableTableWithGenericCommand represents the variable table with generic command
+var VariableTableWithGenericCommand = jdwp.Command{Commandset: 6, Command: 5, HasCommandData: true, HasReplyData: true}
+
+// VariableTableWithGenericCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_VariableTableWithGeneric
+type VariableTableWithGenericCommandData struct {
+	RefType  basetypes.JWDPRefTypeID
+	MethodID basetypes.JWDPMethodID
+}
+
+// VariableTableWithGenericReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_Method_VariableTableWithGeneric
+type VariableTableWithGenericReply struct {
+	ArgCnt   int32
+	NumSlots int32
+	Slots    []VariableWithGeneric `struct:"sizefrom=NumSlots"`
+}
+
+func (v *VariableTableWithGenericReply) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("ArgCnt: %v\n", v.ArgCnt))
+	for _, variable := range v.Slots {
+		builder.WriteString(fmt.Sprintf("{%s}\n", variable.String()))
+	}
+	return builder.String()
+}
+
+// VisibleAt returns the variables in scope at a code index
+func (v *VariableTableWithGenericReply) VisibleAt(index int64) []VariableWithGeneric {
+	var visible []VariableWithGeneric
+	for _, variable := range v.Slots {
+		if variable.InScope(index) {
+			visible = append(visible, variable)
+		}
+	}
+	return visible
+}
+
+// VariableWithGeneric represents a single variable in
+// VariableTableWithGenericReply
+type VariableWithGeneric struct {
+	CodeIndex int64
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	// GenericSignature is empty if there is none
+	GenericSignature basetypes.JDWPString
+	Length           int32
+	Slot             int32
+}
+
+func (v *VariableWithGeneric) String() string {
+	return fmt.Sprintf("CodeIndex: %v Name: %s Signature: %s GenericSignature: %s Length: %v Slot: %v",
+		v.CodeIndex,
+		v.Name.String(),
+		v.Signature.String(),
+		v.GenericSignature.String(),
+		v.Length,
+		v.Slot)
+}
+
+// InScope reports whether the variable is in scope at a code index
+func (v *VariableWithGeneric) InScope(index int64) bool {
+	return index >= v.CodeIndex && index < v.CodeIndex+int64(v.Length)
+}
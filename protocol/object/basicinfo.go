@@ -0,0 +1,48 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// ReferenceTypeCommand represents the reference type command
+var ReferenceTypeCommand = jdwp.Command{Commandset: 9, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// ReferenceTypeCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_ReferenceType
+type ReferenceTypeCommandData struct {
+	Object basetypes.JWDPObjectID
+}
+
+// ReferenceTypeReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_ReferenceType
+type ReferenceTypeReply struct {
+	RefTypeTag basetypes.JWDPTypeTag
+	TypeID     basetypes.JWDPRefTypeID
+}
+
+func (r *ReferenceTypeReply) String() string {
+	return fmt.Sprintf("RefTypeTag: %v TypeID: %s",
+		r.RefTypeTag.String(),
+		r.TypeID.String())
+}
+
+// ReferringObjectsCommand represents the referring objects command
+var ReferringObjectsCommand = jdwp.Command{Commandset: 9, Command: 10, HasCommandData: true, HasReplyData: true}
+
+// ReferringObjectsCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_ReferringObjects
+type ReferringObjectsCommandData struct {
+	Object basetypes.JWDPObjectID
+	// MaxReferrers of 0 returns all referrers
+	MaxReferrers int32
+}
+
+// ReferringObjectsReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_ReferringObjects
+type ReferringObjectsReply struct {
+	NumReferringObjects int32
+	ReferringObjects    []basetypes.JWDPTaggedObjectID `struct:"sizefrom=NumReferringObjects"`
+}
@@ -0,0 +1,39 @@
+package object
+
+import (
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// DisableCollectionCommand represents the disable collection command
+var DisableCollectionCommand = jdwp.Command{Commandset: 9, Command: 7, HasCommandData: true}
+
+// DisableCollectionCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_DisableCollection
+type DisableCollectionCommandData struct {
+	Object basetypes.JWDPObjectID
+}
+
+// EnableCollectionCommand represents the enable collection command
+var EnableCollectionCommand = jdwp.Command{Commandset: 9, Command: 8, HasCommandData: true}
+
+// EnableCollectionCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_EnableCollection
+type EnableCollectionCommandData struct {
+	Object basetypes.JWDPObjectID
+}
+
+// IsCollectedCommand represents the is collected command
+var IsCollectedCommand = jdwp.Command{Commandset: 9, Command: 9, HasCommandData: true, HasReplyData: true}
+
+// IsCollectedCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_IsCollected
+type IsCollectedCommandData struct {
+	Object basetypes.JWDPObjectID
+}
+
+// IsCollectedReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_IsCollected
+type IsCollectedReply struct {
+	IsCollected bool
+}
@@ -0,0 +1,38 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// InvokeMethodCommand represents the invoke method command
+var InvokeMethodCommand = jdwp.Command{Commandset: 9, Command: 6, HasCommandData: true, HasReplyData: true}
+
+// InvokeMethodCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_InvokeMethod
+type InvokeMethodCommandData struct {
+	Object       basetypes.JWDPObjectID
+	Thread       common.ThreadID
+	Clazz        basetypes.JWDPRefTypeID
+	MethodID     basetypes.JWDPMethodID
+	NumArguments int32
+	Arguments    []basetypes.JWDPValue `struct:"sizefrom=NumArguments"`
+	Options      common.InvokeOptions
+}
+
+// InvokeMethodReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_InvokeMethod
+type InvokeMethodReply struct {
+	ReturnValue basetypes.JWDPValue
+	// Exception is null unless the method threw
+	Exception basetypes.JWDPTaggedObjectID
+}
+
+func (i *InvokeMethodReply) String() string {
+	return fmt.Sprintf("ReturnValue: %s Exception: %s",
+		i.ReturnValue.String(),
+		i.Exception.String())
+}
@@ -0,0 +1,35 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// MonitorInfoCommand represents the monitor info command
+var MonitorInfoCommand = jdwp.Command{Commandset: 9, Command: 5, HasCommandData: true, HasReplyData: true}
+
+// MonitorInfoCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_MonitorInfo
+type MonitorInfoCommandData struct {
+	Object basetypes.JWDPObjectID
+}
+
+// MonitorInfoReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_MonitorInfo
+type MonitorInfoReply struct {
+	// Owner is null if the monitor is not owned
+	Owner      common.ThreadID
+	EntryCount int32
+	NumWaiters int32
+	Waiters    []common.ThreadID `struct:"sizefrom=NumWaiters"`
+}
+
+func (m *MonitorInfoReply) String() string {
+	return fmt.Sprintf("Owner: %s EntryCount: %v Waiters: %v",
+		m.Owner.String(),
+		m.EntryCount,
+		m.NumWaiters)
+}
@@ -0,0 +1,50 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// GetValuesCommand represents the get values command
+var GetValuesCommand = jdwp.Command{Commandset: 9, Command: 2, HasCommandData: true, HasReplyData: true}
+
+// GetValuesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_GetValues
+type GetValuesCommandData struct {
+	Object    basetypes.JWDPObjectID
+	NumFields int32
+	Fields    []basetypes.JWDPFieldID `struct:"sizefrom=NumFields"`
+}
+
+// GetValuesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_GetValues
+type GetValuesReply struct {
+	NumValues int32
+	Values    []basetypes.JWDPValue `struct:"sizefrom=NumValues"`
+}
+
+// SetValuesCommand represents the set values command
+var SetValuesCommand = jdwp.Command{Commandset: 9, Command: 3, HasCommandData: true}
+
+// SetValuesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ObjectReference_SetValues
+type SetValuesCommandData struct {
+	Object    basetypes.JWDPObjectID
+	NumValues int32
+	Values    []FieldValue `struct:"sizefrom=NumValues"`
+}
+
+// FieldValue represents a single field value in SetValuesCommandData;
+// the value's tag must match the field's type, as it is sent untagged
+type FieldValue struct {
+	FieldID basetypes.JWDPFieldID
+	Value   basetypes.JWDPUntaggedValue
+}
+
+func (f *FieldValue) String() string {
+	return fmt.Sprintf("FieldID: %s Value: %s",
+		f.FieldID.String(),
+		f.Value.String())
+}
@@ -0,0 +1,138 @@
+package reftype
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/vm"
+)
+
+// SignatureCommand represents the signature command
+var SignatureCommand = jdwp.Command{Commandset: 2, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// SignatureCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Signature
+type SignatureCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// SignatureReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Signature
+type SignatureReply struct {
+	Signature basetypes.JDWPString
+}
+
+// ClassLoaderCommand represents the class loader command
+var ClassLoaderCommand = jdwp.Command{Commandset: 2, Command: 2, HasCommandData: true, HasReplyData: true}
+
+// ClassLoaderCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassLoader
+type ClassLoaderCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ClassLoaderReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassLoader
+type ClassLoaderReply struct {
+	// ClassLoader is null for the bootstrap loader
+	ClassLoader basetypes.JWDPObjectID
+}
+
+// ModifiersCommand represents the modifiers command
+var ModifiersCommand = jdwp.Command{Commandset: 2, Command: 3, HasCommandData: true, HasReplyData: true}
+
+// ModifiersCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Modifiers
+type ModifiersCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ModifiersReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Modifiers
+type ModifiersReply struct {
+	ModBits int32
+}
+
+// SourceFileCommand represents the source file command
+var SourceFileCommand = jdwp.Command{Commandset: 2, Command: 7, HasCommandData: true, HasReplyData: true}
+
+// SourceFileCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SourceFile
+type SourceFileCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// SourceFileReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SourceFile
+type SourceFileReply struct {
+	SourceFile basetypes.JDWPString
+}
+
+// StatusCommand represents the status command
+var StatusCommand = jdwp.Command{Commandset: 2, Command: 9, HasCommandData: true, HasReplyData: true}
+
+// StatusCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Status
+type StatusCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// StatusReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Status
+type StatusReply struct {
+	Status vm.AllClassClassStatus
+}
+
+// ClassObjectCommand represents the class object command
+var ClassObjectCommand = jdwp.Command{Commandset: 2, Command: 11, HasCommandData: true, HasReplyData: true}
+
+// ClassObjectCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassObject
+type ClassObjectCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ClassObjectReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassObject
+type ClassObjectReply struct {
+	ClassObject basetypes.JWDPObjectID
+}
+
+// SignatureWithGenericCommand represents the signature with generic command
+var SignatureWithGenericCommand = jdwp.Command{Commandset: 2, Command: 13, HasCommandData: true, HasReplyData: true}
+
+// SignatureWithGenericCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SignatureWithGeneric
+type SignatureWithGenericCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// SignatureWithGenericReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SignatureWithGeneric
+type SignatureWithGenericReply struct {
+	Signature basetypes.JDWPString
+	// GenericSignature is empty if there is none
+	GenericSignature basetypes.JDWPString
+}
+
+func (s *SignatureWithGenericReply) String() string {
+	return fmt.Sprintf("Signature: %s GenericSignature: %s",
+		s.Signature.String(),
+		s.GenericSignature.String())
+}
+
+// ModuleCommand represents the module command
+var ModuleCommand = jdwp.Command{Commandset: 2, Command: 19, HasCommandData: true, HasReplyData: true}
+
+// ModuleCommandData represents
+// https://docs.oracle.com/en/java/javase/21/docs/specs/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Module
+type ModuleCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ModuleReply represents
+// https://docs.oracle.com/en/java/javase/21/docs/specs/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Module
+type ModuleReply struct {
+	Module basetypes.JWDPObjectID
+}
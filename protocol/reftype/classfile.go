@@ -0,0 +1,66 @@
+package reftype
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// SourceDebugExtensionCommand represents the source debug extension command
+var SourceDebugExtensionCommand = jdwp.Command{Commandset: 2, Command: 12, HasCommandData: true, HasReplyData: true}
+
+// SourceDebugExtensionCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SourceDebugExtension
+type SourceDebugExtensionCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// SourceDebugExtensionReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_SourceDebugExtension
+type SourceDebugExtensionReply struct {
+	Extension basetypes.JDWPString
+}
+
+// ClassFileVersionCommand represents the class file version command
+var ClassFileVersionCommand = jdwp.Command{Commandset: 2, Command: 17, HasCommandData: true, HasReplyData: true}
+
+// ClassFileVersionCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassFileVersion
+type ClassFileVersionCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ClassFileVersionReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ClassFileVersion
+type ClassFileVersionReply struct {
+	MajorVersion int32
+	MinorVersion int32
+}
+
+func (c *ClassFileVersionReply) String() string {
+	return fmt.Sprintf("%v.%v", c.MajorVersion, c.MinorVersion)
+}
+
+// ConstantPoolCommand represents the constant pool command
+var ConstantPoolCommand = jdwp.Command{Commandset: 2, Command: 18, HasCommandData: true, HasReplyData: true}
+
+// ConstantPoolCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ConstantPool
+type ConstantPoolCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// ConstantPoolReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_ConstantPool
+type ConstantPoolReply struct {
+	// Count is constant_pool_count from the class file
+	Count    int32
+	NumBytes int32
+	// Bytes are the raw constant_pool entries in class file format
+	Bytes []byte `struct:"sizefrom=NumBytes"`
+}
+
+func (c *ConstantPoolReply) String() string {
+	return fmt.Sprintf("Count: %v Bytes: %v", c.Count, c.NumBytes)
+}
@@ -0,0 +1,79 @@
+package reftype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// NestedTypesCommand represents the nested types command
+var NestedTypesCommand = jdwp.Command{Commandset: 2, Command: 8, HasCommandData: true, HasReplyData: true}
+
+// NestedTypesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_NestedTypes
+type NestedTypesCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// NestedTypesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_NestedTypes
+type NestedTypesReply struct {
+	NumClasses int32
+	Classes    []NestedType `struct:"sizefrom=NumClasses"`
+}
+
+func (n *NestedTypesReply) String() string {
+	var builder strings.Builder
+	for _, class := range n.Classes {
+		builder.WriteString(fmt.Sprintf("{%s}\n", class.String()))
+	}
+	return builder.String()
+}
+
+// NestedType represents a single type in NestedTypesReply
+type NestedType struct {
+	RefTypeTag basetypes.JWDPTypeTag
+	TypeID     basetypes.JWDPRefTypeID
+}
+
+func (n *NestedType) String() string {
+	return fmt.Sprintf("RefTypeTag: %v TypeID: %s",
+		n.RefTypeTag.String(),
+		n.TypeID.String())
+}
+
+// InterfacesCommand represents the interfaces command
+var InterfacesCommand = jdwp.Command{Commandset: 2, Command: 10, HasCommandData: true, HasReplyData: true}
+
+// InterfacesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Interfaces
+type InterfacesCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// InterfacesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Interfaces
+type InterfacesReply struct {
+	NumInterfaces int32
+	Interfaces    []basetypes.JWDPRefTypeID `struct:"sizefrom=NumInterfaces"`
+}
+
+// InstancesCommand represents the instances command
+var InstancesCommand = jdwp.Command{Commandset: 2, Command: 16, HasCommandData: true, HasReplyData: true}
+
+// InstancesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Instances
+type InstancesCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+	// MaxInstances of 0 returns all instances
+	MaxInstances int32
+}
+
+// InstancesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Instances
+type InstancesReply struct {
+	NumInstances int32
+	Instances    []basetypes.JWDPTaggedObjectID `struct:"sizefrom=NumInstances"`
+}
@@ -0,0 +1,214 @@
+package reftype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// MethodsCommand represents the methods command
+var MethodsCommand = jdwp.Command{Commandset: 2, Command: 5, HasCommandData: true, HasReplyData: true}
+
+// MethodsCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Methods
+type MethodsCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// MethodsReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Methods
+type MethodsReply struct {
+	NumDeclared int32
+	Declared    []Method `struct:"sizefrom=NumDeclared"`
+}
+
+func (m *MethodsReply) String() string {
+	var builder strings.Builder
+	for _, method := range m.Declared {
+		builder.WriteString(fmt.Sprintf("{%s}\n", method.String()))
+	}
+	return builder.String()
+}
+
+// Find returns the declared method with the given ID, if any
+func (m *MethodsReply) Find(methodID basetypes.JWDPMethodID) (*Method, bool) {
+	for idx := range m.Declared {
+		if m.Declared[idx].MethodID == methodID {
+			return &m.Declared[idx], true
+		}
+	}
+	return nil, false
+}
+
+// Method represents a single method in MethodsReply
+type Method struct {
+	MethodID  basetypes.JWDPMethodID
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	ModBits   int32
+}
+
+func (m *Method) String() string {
+	return fmt.Sprintf("MethodID: %s Name: %s Signature: %s ModBits: 0x%X",
+		m.MethodID.String(),
+		m.Name.String(),
+		m.Signature.String(),
+		m.ModBits)
+}
+
+// MethodsWithGenericCommand represents the methods with generic command
+var MethodsWithGenericCommand = jdwp.Command{Commandset: 2, Command: 15, HasCommandData: true, HasReplyData: true}
+
+// MethodsWithGenericCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_MethodsWithGeneric
+type MethodsWithGenericCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// MethodsWithGenericReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_MethodsWithGeneric
+type MethodsWithGenericReply struct {
+	NumDeclared int32
+	Declared    []MethodWithGeneric `struct:"sizefrom=NumDeclared"`
+}
+
+func (m *MethodsWithGenericReply) String() string {
+	var builder strings.Builder
+	for _, method := range m.Declared {
+		builder.WriteString(fmt.Sprintf("{%s}\n", method.String()))
+	}
+	return builder.String()
+}
+
+// MethodWithGeneric represents a single method in MethodsWithGenericReply
+type MethodWithGeneric struct {
+	MethodID  basetypes.JWDPMethodID
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	// GenericSignature is empty if there is none
+	GenericSignature basetypes.JDWPString
+	ModBits          int32
+}
+
+func (m *MethodWithGeneric) String() string {
+	return fmt.Sprintf("MethodID: %s Name: %s Signature: %s GenericSignature: %s ModBits: 0x%X",
+		m.MethodID.String(),
+		m.Name.String(),
+		m.Signature.String(),
+		m.GenericSignature.String(),
+		m.ModBits)
+}
+
+// FieldsCommand represents the fields command
+var FieldsCommand = jdwp.Command{Commandset: 2, Command: 4, HasCommandData: true, HasReplyData: true}
+
+// FieldsCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Fields
+type FieldsCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// FieldsReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_Fields
+type FieldsReply struct {
+	NumDeclared int32
+	Declared    []Field `struct:"sizefrom=NumDeclared"`
+}
+
+func (f *FieldsReply) String() string {
+	var builder strings.Builder
+	for _, field := range f.Declared {
+		builder.WriteString(fmt.Sprintf("{%s}\n", field.String()))
+	}
+	return builder.String()
+}
+
+// FindByName returns the declared field with the given name, if any
+func (f *FieldsReply) FindByName(name string) (*Field, bool) {
+	for idx := range f.Declared {
+		if f.Declared[idx].Name.String() == name {
+			return &f.Declared[idx], true
+		}
+	}
+	return nil, false
+}
+
+// Field represents a single field in FieldsReply
+type Field struct {
+	FieldID   basetypes.JWDPFieldID
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	ModBits   int32
+}
+
+func (f *Field) String() string {
+	return fmt.Sprintf("FieldID: %s Name: %s Signature: %s ModBits: 0x%X",
+		f.FieldID.String(),
+		f.Name.String(),
+		f.Signature.String(),
+		f.ModBits)
+}
+
+// FieldsWithGenericCommand represents the fields with generic command
+var FieldsWithGenericCommand = jdwp.Command{Commandset: 2, Command: 14, HasCommandData: true, HasReplyData: true}
+
+// FieldsWithGenericCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_FieldsWithGeneric
+type FieldsWithGenericCommandData struct {
+	RefType basetypes.JWDPRefTypeID
+}
+
+// FieldsWithGenericReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_FieldsWithGeneric
+type FieldsWithGenericReply struct {
+	NumDeclared int32
+	Declared    []FieldWithGeneric `struct:"sizefrom=NumDeclared"`
+}
+
+func (f *FieldsWithGenericReply) String() string {
+	var builder strings.Builder
+	for _, field := range f.Declared {
+		builder.WriteString(fmt.Sprintf("{%s}\n", field.String()))
+	}
+	return builder.String()
+}
+
+// FieldWithGeneric represents a single field in FieldsWithGenericReply
+type FieldWithGeneric struct {
+	FieldID   basetypes.JWDPFieldID
+	Name      basetypes.JDWPString
+	Signature basetypes.JDWPString
+	// GenericSignature is empty if there is none
+	GenericSignature basetypes.JDWPString
+	ModBits          int32
+}
+
+func (f *FieldWithGeneric) String() string {
+	return fmt.Sprintf("FieldID: %s Name: %s Signature: %s GenericSignature: %s ModBits: 0x%X",
+		f.FieldID.String(),
+		f.Name.String(),
+		f.Signature.String(),
+		f.GenericSignature.String(),
+		f.ModBits)
+}
+
+// GetValuesCommand represents the get values command
+var GetValuesCommand = jdwp.Command{Commandset: 2, Command: 6, HasCommandData: true, HasReplyData: true}
+
+// GetValuesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_GetValues
+type GetValuesCommandData struct {
+	RefType   basetypes.JWDPRefTypeID
+	NumFields int32
+	// Fields must be static fields of RefType or its supertypes
+	Fields []basetypes.JWDPFieldID `struct:"sizefrom=NumFields"`
+}
+
+// GetValuesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ReferenceType_GetValues
+type GetValuesReply struct {
+	NumValues int32
+	Values    []basetypes.JWDPValue `struct:"sizefrom=NumValues"`
+}
@@ -0,0 +1,35 @@
+package stackframe
+
+import (
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// ThisObjectCommand represents the this object command
+var ThisObjectCommand = jdwp.Command{Commandset: 16, Command: 3, HasCommandData: true, HasReplyData: true}
+
+// ThisObjectCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_ThisObject
+type ThisObjectCommandData struct {
+	Thread common.ThreadID
+	Frame  basetypes.JWDPFrameID
+}
+
+// ThisObjectReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_ThisObject
+type ThisObjectReply struct {
+	// ObjectThis is null for static and native methods
+	ObjectThis basetypes.JWDPTaggedObjectID
+}
+
+// PopFramesCommand represents the pop frames command
+var PopFramesCommand = jdwp.Command{Commandset: 16, Command: 4, HasCommandData: true}
+
+// PopFramesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_PopFrames
+type PopFramesCommandData struct {
+	Thread common.ThreadID
+	// Frame is the last frame to pop; it and all frames above it go
+	Frame basetypes.JWDPFrameID
+}
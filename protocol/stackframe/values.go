@@ -0,0 +1,61 @@
+package stackframe
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// GetValuesCommand represents the get values command
+var GetValuesCommand = jdwp.Command{Commandset: 16, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// GetValuesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_GetValues
+type GetValuesCommandData struct {
+	Thread   common.ThreadID
+	Frame    basetypes.JWDPFrameID
+	NumSlots int32
+	Slots    []Slot `struct:"sizefrom=NumSlots"`
+}
+
+// Slot represents a single slot in GetValuesCommandData
+type Slot struct {
+	Slot int32
+	// SigByte is the tag of the variable's type, from its signature
+	SigByte basetypes.JWDPTag
+}
+
+func (s *Slot) String() string {
+	return fmt.Sprintf("Slot: %v SigByte: %v", s.Slot, s.SigByte)
+}
+
+// GetValuesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_GetValues
+type GetValuesReply struct {
+	NumValues int32
+	Values    []basetypes.JWDPValue `struct:"sizefrom=NumValues"`
+}
+
+// SetValuesCommand represents the set values command
+var SetValuesCommand = jdwp.Command{Commandset: 16, Command: 2, HasCommandData: true}
+
+// SetValuesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StackFrame_SetValues
+type SetValuesCommandData struct {
+	Thread        common.ThreadID
+	Frame         basetypes.JWDPFrameID
+	NumSlotValues int32
+	SlotValues    []SlotValue `struct:"sizefrom=NumSlotValues"`
+}
+
+// SlotValue represents a single slot value in SetValuesCommandData
+type SlotValue struct {
+	Slot  int32
+	Value basetypes.JWDPValue
+}
+
+func (s *SlotValue) String() string {
+	return fmt.Sprintf("Slot: %v Value: %s", s.Slot, s.Value.String())
+}
@@ -0,0 +1,27 @@
+package stringref
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+)
+
+// ValueCommand represents the value command
+var ValueCommand = jdwp.Command{Commandset: 10, Command: 1, HasCommandData: true, HasReplyData: true}
+
+// ValueCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StringReference_Value
+type ValueCommandData struct {
+	StringObject basetypes.JWDPObjectID
+}
+
+// ValueReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_StringReference_Value
+type ValueReply struct {
+	StringValue basetypes.JDWPString
+}
+
+func (v *ValueReply) String() string {
+	return fmt.Sprintf("StringValue: %s", v.StringValue.String())
+}
@@ -20,3 +20,34 @@ type NameCommandData struct {
 type NameReply struct {
 	ThreadName basetypes.JDWPString
 }
+
+// ThreadGroupCommand represents the thread group command
+var ThreadGroupCommand = jdwp.Command{Commandset: 11, Command: 5, HasCommandData: true, HasReplyData: true}
+
+// ThreadGroupCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_ThreadGroup
+type ThreadGroupCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// ThreadGroupReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_ThreadGroup
+type ThreadGroupReply struct {
+	Group common.ThreadGroupID
+}
+
+// IsVirtualCommand represents the is virtual command; it is only
+// understood by JDK 21 and later VMs
+var IsVirtualCommand = jdwp.Command{Commandset: 11, Command: 15, HasCommandData: true, HasReplyData: true}
+
+// IsVirtualCommandData represents
+// https://docs.oracle.com/en/java/javase/21/docs/specs/jdwp/jdwp-protocol.html#JDWP_ThreadReference_IsVirtual
+type IsVirtualCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// IsVirtualReply represents
+// https://docs.oracle.com/en/java/javase/21/docs/specs/jdwp/jdwp-protocol.html#JDWP_ThreadReference_IsVirtual
+type IsVirtualReply struct {
+	IsVirtual bool
+}
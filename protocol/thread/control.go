@@ -0,0 +1,69 @@
+package thread
+
+import (
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// SuspendCommand represents the suspend command
+var SuspendCommand = jdwp.Command{Commandset: 11, Command: 2, HasCommandData: true}
+
+// SuspendCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Suspend
+type SuspendCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// ResumeCommand represents the resume command
+var ResumeCommand = jdwp.Command{Commandset: 11, Command: 3, HasCommandData: true}
+
+// ResumeCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Resume
+type ResumeCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// StopCommand represents the stop command
+var StopCommand = jdwp.Command{Commandset: 11, Command: 10, HasCommandData: true}
+
+// StopCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Stop
+type StopCommandData struct {
+	ThreadID  common.ThreadID
+	Throwable basetypes.JWDPObjectID
+}
+
+// InterruptCommand represents the interrupt command
+var InterruptCommand = jdwp.Command{Commandset: 11, Command: 11, HasCommandData: true}
+
+// InterruptCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Interrupt
+type InterruptCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// SuspendCountCommand represents the suspend count command
+var SuspendCountCommand = jdwp.Command{Commandset: 11, Command: 12, HasCommandData: true, HasReplyData: true}
+
+// SuspendCountCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_SuspendCount
+type SuspendCountCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// SuspendCountReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_SuspendCount
+type SuspendCountReply struct {
+	SuspendCount int32
+}
+
+// ForceEarlyReturnCommand represents the force early return command
+var ForceEarlyReturnCommand = jdwp.Command{Commandset: 11, Command: 14, HasCommandData: true}
+
+// ForceEarlyReturnCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_ForceEarlyReturn
+type ForceEarlyReturnCommandData struct {
+	ThreadID common.ThreadID
+	Value    basetypes.JWDPValue
+}
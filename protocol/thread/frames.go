@@ -0,0 +1,64 @@
+package thread
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// FramesCommand represents the frames command
+var FramesCommand = jdwp.Command{Commandset: 11, Command: 6, HasCommandData: true, HasReplyData: true}
+
+// FramesCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Frames
+type FramesCommandData struct {
+	ThreadID   common.ThreadID
+	StartFrame int32
+	// Length of -1 means all remaining frames
+	Length int32
+}
+
+// FramesReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Frames
+type FramesReply struct {
+	NumFrames int32
+	Frames    []Frame `struct:"sizefrom=NumFrames"`
+}
+
+func (f *FramesReply) String() string {
+	var builder strings.Builder
+	for _, frame := range f.Frames {
+		builder.WriteString(fmt.Sprintf("{%s}\n", frame.String()))
+	}
+	return builder.String()
+}
+
+// Frame represents a single frame in FramesReply
+type Frame struct {
+	FrameID  basetypes.JWDPFrameID
+	Location common.Location
+}
+
+func (f *Frame) String() string {
+	return fmt.Sprintf("FrameID: %s Location: {%s}",
+		f.FrameID.String(),
+		f.Location.String())
+}
+
+// FrameCountCommand represents the frame count command
+var FrameCountCommand = jdwp.Command{Commandset: 11, Command: 7, HasCommandData: true, HasReplyData: true}
+
+// FrameCountCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_FrameCount
+type FrameCountCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// FrameCountReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_FrameCount
+type FrameCountReply struct {
+	FrameCount int32
+}
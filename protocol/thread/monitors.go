@@ -0,0 +1,85 @@
+package thread
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// OwnedMonitorsCommand represents the owned monitors command
+var OwnedMonitorsCommand = jdwp.Command{Commandset: 11, Command: 8, HasCommandData: true, HasReplyData: true}
+
+// OwnedMonitorsCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_OwnedMonitors
+type OwnedMonitorsCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// OwnedMonitorsReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_OwnedMonitors
+type OwnedMonitorsReply struct {
+	NumOwned int32
+	Owned    []basetypes.JWDPTaggedObjectID `struct:"sizefrom=NumOwned"`
+}
+
+func (o *OwnedMonitorsReply) String() string {
+	var builder strings.Builder
+	for _, monitor := range o.Owned {
+		builder.WriteString(fmt.Sprintf("%s\n", monitor.String()))
+	}
+	return builder.String()
+}
+
+// CurrentContendedMonitorCommand represents the current contended monitor command
+var CurrentContendedMonitorCommand = jdwp.Command{Commandset: 11, Command: 9, HasCommandData: true, HasReplyData: true}
+
+// CurrentContendedMonitorCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_CurrentContendedMonitor
+type CurrentContendedMonitorCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// CurrentContendedMonitorReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_CurrentContendedMonitor
+type CurrentContendedMonitorReply struct {
+	Monitor basetypes.JWDPTaggedObjectID
+}
+
+// OwnedMonitorsStackDepthInfoCommand represents the owned monitors stack depth info command
+var OwnedMonitorsStackDepthInfoCommand = jdwp.Command{Commandset: 11, Command: 13, HasCommandData: true, HasReplyData: true}
+
+// OwnedMonitorsStackDepthInfoCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_OwnedMonitorsStackDepthInfo
+type OwnedMonitorsStackDepthInfoCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// OwnedMonitorsStackDepthInfoReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_OwnedMonitorsStackDepthInfo
+type OwnedMonitorsStackDepthInfoReply struct {
+	NumOwned int32
+	Owned    []OwnedMonitorStackDepth `struct:"sizefrom=NumOwned"`
+}
+
+func (o *OwnedMonitorsStackDepthInfoReply) String() string {
+	var builder strings.Builder
+	for _, monitor := range o.Owned {
+		builder.WriteString(fmt.Sprintf("{%s}\n", monitor.String()))
+	}
+	return builder.String()
+}
+
+// OwnedMonitorStackDepth represents a single monitor in OwnedMonitorsStackDepthInfoReply
+type OwnedMonitorStackDepth struct {
+	Monitor    basetypes.JWDPTaggedObjectID
+	StackDepth int32
+}
+
+func (o *OwnedMonitorStackDepth) String() string {
+	return fmt.Sprintf("Monitor: %s StackDepth: %v",
+		o.Monitor.String(),
+		o.StackDepth)
+}
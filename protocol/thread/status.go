@@ -0,0 +1,83 @@
+package thread
+
+import (
+	"fmt"
+
+	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/common"
+)
+
+// StatusCommand represents the status command
+var StatusCommand = jdwp.Command{Commandset: 11, Command: 4, HasCommandData: true, HasReplyData: true}
+
+// StatusCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Status
+type StatusCommandData struct {
+	ThreadID common.ThreadID
+}
+
+// StatusReply represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_ThreadReference_Status
+type StatusReply struct {
+	ThreadStatus  Status
+	SuspendStatus SuspendStatus
+}
+
+func (s *StatusReply) String() string {
+	return fmt.Sprintf("ThreadStatus: %v SuspendStatus: %v",
+		s.ThreadStatus.String(),
+		s.SuspendStatus.String())
+}
+
+// Status represents a thread's status
+type Status int32
+
+const (
+	// StatusZombie - thread has terminated
+	StatusZombie Status = 0
+	// StatusRunning - thread is running
+	StatusRunning Status = 1
+	// StatusSleeping - thread is sleeping
+	StatusSleeping Status = 2
+	// StatusMonitor - thread is waiting to enter a monitor
+	StatusMonitor Status = 3
+	// StatusWait - thread is waiting in Object.wait
+	StatusWait Status = 4
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusZombie:
+		return "Zombie"
+	case StatusRunning:
+		return "Running"
+	case StatusSleeping:
+		return "Sleeping"
+	case StatusMonitor:
+		return "Monitor"
+	case StatusWait:
+		return "Wait"
+	default:
+		return "Unknown"
+	}
+}
+
+// SuspendStatus represents a thread's suspend status
+type SuspendStatus int32
+
+const (
+	// SuspendStatusSuspended - thread is suspended
+	SuspendStatusSuspended SuspendStatus = 1
+)
+
+// IsSuspended reports whether the suspended flag is set
+func (s SuspendStatus) IsSuspended() bool {
+	return s&SuspendStatusSuspended != 0
+}
+
+func (s SuspendStatus) String() string {
+	if s.IsSuspended() {
+		return "Suspended"
+	}
+	return "NotSuspended"
+}
@@ -8,6 +8,45 @@ import (
 	"github.com/jquirke/jdwpgo/protocol/basetypes"
 )
 
+// ClassesBySignatureCommand represents the classes by signature command
+var ClassesBySignatureCommand = jdwp.Command{Commandset: 1, Command: 2, HasCommandData: true, HasReplyData: true}
+
+// ClassesBySignatureCommandData represents
+// https://docs.oracle.com/javase/7/docs/platform/jpda/jdwp/jdwp-protocol.html#JDWP_VirtualMachine_ClassesBySignature
+type ClassesBySignatureCommandData struct {
+	Signature basetypes.JDWPString
+}
+
+// ClassesBySignatureReply represents the loaded classes matching a
+// signature; there is one per class loader that has loaded it
+type ClassesBySignatureReply struct {
+	NumClasses int32
+	Classes    []ClassBySignature `struct:"sizefrom=NumClasses"`
+}
+
+func (c *ClassesBySignatureReply) String() string {
+	var builder strings.Builder
+	for _, class := range c.Classes {
+		builder.WriteString(fmt.Sprintf("{%s}\n", class.String()))
+	}
+	return builder.String()
+}
+
+// ClassBySignature represents a single class in ClassesBySignatureReply
+type ClassBySignature struct {
+	RefTypeTag      basetypes.JWDPTypeTag
+	ReferenceTypeID basetypes.JWDPRefTypeID
+	Status          AllClassClassStatus
+}
+
+func (c *ClassBySignature) String() string {
+	return fmt.Sprintf("RefTypeTag: %v ReferenceTypeID: %s Status: %v",
+		c.RefTypeTag.String(),
+		c.ReferenceTypeID.String(),
+		c.Status.String(),
+	)
+}
+
 // AllClassesCommand represents the all classes command
 var AllClassesCommand = jdwp.Command{Commandset: 1, Command: 3, HasReplyData: true}
 
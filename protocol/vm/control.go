@@ -2,6 +2,9 @@ package vm
 
 import "github.com/jquirke/jdwpgo/api/jdwp"
 
+// DisposeCommand represents the dispose command
+var DisposeCommand = jdwp.Command{Commandset: 1, Command: 6}
+
 // SuspendCommand represents the suspendcommand
 var SuspendCommand = jdwp.Command{Commandset: 1, Command: 8}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/jquirke/jdwpgo/api/jdwp"
+	"github.com/jquirke/jdwpgo/protocol/basetypes"
 )
 
 // IDSizesCommand represents the IDSizes command
@@ -27,3 +28,14 @@ func (i *IDSizesReply) String() string {
 		i.ReferenceTypeIDSize,
 		i.FrameIDSize)
 }
+
+// IDSizes converts the reply to the sizes used by the serialiser
+func (i *IDSizesReply) IDSizes() basetypes.IDSizes {
+	return basetypes.IDSizes{
+		FieldIDSize:         int(i.FieldIDSize),
+		MethodIDSize:        int(i.MethodIDSize),
+		ObjectIDSize:        int(i.ObjectIDSize),
+		ReferenceTypeIDSize: int(i.ReferenceTypeIDSize),
+		FrameIDSize:         int(i.FrameIDSize),
+	}
+}